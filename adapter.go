@@ -4,6 +4,8 @@ package limiter
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 )
 
@@ -13,3 +15,378 @@ type Adapter interface {
 	IncrBy(ctx context.Context, key string, value int64) error
 	SumKeys(ctx context.Context, keys []string) (int64, error)
 }
+
+// BatchAdapter is implemented by the adapters able to run several operations in a single round-trip.
+// The limiter falls back to one call per key, or group of keys, for the other adapters.
+type BatchAdapter interface {
+	// IncrByKeys increments every key by value.
+	IncrByKeys(ctx context.Context, keys []string, value int64) error
+	// SumKeyGroups returns the sum of every group of keys.
+	SumKeyGroups(ctx context.Context, groups [][]string) ([]int64, error)
+}
+
+// IncrByKeys increments every key by value, in a single round-trip if the adapter is a BatchAdapter.
+func IncrByKeys(ctx context.Context, adapter Adapter, keys []string, value int64) error {
+	if batch, ok := adapter.(BatchAdapter); ok {
+		return batch.IncrByKeys(ctx, keys, value)
+	}
+
+	for _, key := range keys {
+		if err := adapter.IncrBy(ctx, key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SumKeyGroups returns the sum of every group of keys, in a single round-trip if the adapter is a BatchAdapter.
+func SumKeyGroups(ctx context.Context, adapter Adapter, groups [][]string) ([]int64, error) {
+	if batch, ok := adapter.(BatchAdapter); ok {
+		return batch.SumKeyGroups(ctx, groups)
+	}
+
+	sums := make([]int64, len(groups))
+	for i, keys := range groups {
+		sum, err := adapter.SumKeys(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		sums[i] = sum
+	}
+
+	return sums, nil
+}
+
+// ExpiringAdapter is implemented by the adapters able to increment a key expiring on its own.
+type ExpiringAdapter interface {
+	// IncrByExpire increments the key by value and returns its new value.
+	// The key expires after expiration from the increment creating it.
+	IncrByExpire(ctx context.Context, key string, value int64, expiration time.Duration) (int64, error)
+}
+
+// expiringMu serializes the IncrByExpire fallback of the adapters not implementing ExpiringAdapter.
+var expiringMu sync.Mutex
+
+// expiringCounter is how the counters of IncrByExpire are stored by the adapters not implementing ExpiringAdapter.
+type expiringCounter struct {
+	Value int64 `json:"value"`
+	// ExpiresAt is the expiry of the counter in unix nanoseconds.
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// IncrByExpire increments the key by value and returns its new value, natively if the adapter is an ExpiringAdapter.
+// The key expires after expiration from the increment creating it.
+// The other adapters store the counter with Get and Set, only guarded against the concurrent calls of this process.
+func IncrByExpire(ctx context.Context, adapter Adapter, key string, value int64, expiration time.Duration) (int64, error) {
+	if expiring, ok := adapter.(ExpiringAdapter); ok {
+		return expiring.IncrByExpire(ctx, key, value, expiration)
+	}
+
+	expiringMu.Lock()
+	defer expiringMu.Unlock()
+
+	now := Now()
+	var counter expiringCounter
+	if err := adapter.Get(ctx, key, &counter); err != nil && !errors.Is(err, ErrCacheMiss) {
+		return 0, err
+	}
+	if counter.ExpiresAt <= now.UnixNano() {
+		counter = expiringCounter{ExpiresAt: now.Add(expiration).UnixNano()}
+	}
+	counter.Value += value
+
+	return counter.Value, adapter.Set(ctx, key, counter, time.Duration(counter.ExpiresAt-now.UnixNano()))
+}
+
+// Condition requires the sum of the keys plus the cost to stay within the limit.
+type Condition struct {
+	Keys  []string
+	Cost  int64
+	Limit int64
+}
+
+// Increment increments the key by the value.
+type Increment struct {
+	Key   string
+	Value int64
+}
+
+// AtomicAdapter is implemented by the adapters able to check conditions and apply increments atomically.
+type AtomicAdapter interface {
+	// IncrIf applies the increments only if every condition holds.
+	// It returns the index of the first failing condition and its sum, or -1 when the increments were applied.
+	IncrIf(ctx context.Context, conditions []Condition, increments []Increment) (failed int, sum int64, err error)
+}
+
+// incrIfMu serializes the IncrIf fallback of the adapters not implementing AtomicAdapter.
+var incrIfMu sync.Mutex
+
+// IncrIf applies the increments only if every condition holds, atomically if the adapter is an AtomicAdapter.
+// The other adapters are only guarded against the concurrent IncrIf calls of this process.
+func IncrIf(ctx context.Context, adapter Adapter, conditions []Condition, increments []Increment) (int, int64, error) {
+	if atomic, ok := adapter.(AtomicAdapter); ok {
+		return atomic.IncrIf(ctx, conditions, increments)
+	}
+
+	incrIfMu.Lock()
+	defer incrIfMu.Unlock()
+
+	groups := make([][]string, len(conditions))
+	for i, condition := range conditions {
+		groups[i] = condition.Keys
+	}
+
+	sums, err := SumKeyGroups(ctx, adapter, groups)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i, condition := range conditions {
+		if sums[i]+condition.Cost > condition.Limit {
+			return i, sums[i], nil
+		}
+	}
+
+	for _, increment := range increments {
+		if err := adapter.IncrBy(ctx, increment.Key, increment.Value); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return -1, 0, nil
+}
+
+// LeaseAdapter is implemented by the adapters able to manage the leases of a concurrency limit atomically.
+// The leases of a key expire on their own, so the slots of crashed holders are eventually freed.
+type LeaseAdapter interface {
+	// AcquireLease adds the lease to the key if it holds less than limit unexpired leases at now.
+	// Acquiring a lease already held renews it.
+	AcquireLease(ctx context.Context, key, id string, limit int64, now time.Time, ttl time.Duration) (bool, error)
+	// RenewLease extends an unexpired lease, it returns false if the lease is not held.
+	RenewLease(ctx context.Context, key, id string, now time.Time, ttl time.Duration) (bool, error)
+	// ReleaseLease removes the lease from the key.
+	ReleaseLease(ctx context.Context, key, id string) error
+}
+
+// leaseMu serializes the lease fallback of the adapters not implementing LeaseAdapter.
+var leaseMu sync.Mutex
+
+// leases maps the lease IDs of a key to their expiry in unix nanoseconds.
+// It is how the leases are stored by the adapters not implementing LeaseAdapter.
+type leases map[string]int64
+
+func getLeases(ctx context.Context, adapter Adapter, key string, now time.Time) (leases, error) {
+	held := make(leases)
+	if err := adapter.Get(ctx, key, &held); err != nil && !errors.Is(err, ErrCacheMiss) {
+		return nil, err
+	}
+
+	for id, expiresAt := range held {
+		if expiresAt <= now.UnixNano() {
+			delete(held, id)
+		}
+	}
+
+	return held, nil
+}
+
+// setLeases stores the leases until the last one expires, an empty set is only kept briefly.
+func setLeases(ctx context.Context, adapter Adapter, key string, held leases, now time.Time) error {
+	expiration := time.Millisecond
+	for _, expiresAt := range held {
+		if d := time.Duration(expiresAt - now.UnixNano()); d > expiration {
+			expiration = d
+		}
+	}
+
+	return adapter.Set(ctx, key, held, expiration)
+}
+
+// AcquireLease adds the lease to the key if it holds less than limit unexpired leases,
+// atomically if the adapter is a LeaseAdapter.
+// The other adapters store the leases with Get and Set, only guarded against the concurrent calls of this process.
+func AcquireLease(ctx context.Context, adapter Adapter, key, id string, limit int64, now time.Time, ttl time.Duration) (bool, error) {
+	if leaser, ok := adapter.(LeaseAdapter); ok {
+		return leaser.AcquireLease(ctx, key, id, limit, now, ttl)
+	}
+
+	leaseMu.Lock()
+	defer leaseMu.Unlock()
+
+	held, err := getLeases(ctx, adapter, key, now)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := held[id]; !ok && int64(len(held)) >= limit {
+		return false, nil
+	}
+	held[id] = now.Add(ttl).UnixNano()
+
+	return true, setLeases(ctx, adapter, key, held, now)
+}
+
+// RenewLease extends an unexpired lease, atomically if the adapter is a LeaseAdapter.
+func RenewLease(ctx context.Context, adapter Adapter, key, id string, now time.Time, ttl time.Duration) (bool, error) {
+	if leaser, ok := adapter.(LeaseAdapter); ok {
+		return leaser.RenewLease(ctx, key, id, now, ttl)
+	}
+
+	leaseMu.Lock()
+	defer leaseMu.Unlock()
+
+	held, err := getLeases(ctx, adapter, key, now)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := held[id]; !ok {
+		return false, nil
+	}
+	held[id] = now.Add(ttl).UnixNano()
+
+	return true, setLeases(ctx, adapter, key, held, now)
+}
+
+// ReleaseLease removes the lease from the key, atomically if the adapter is a LeaseAdapter.
+func ReleaseLease(ctx context.Context, adapter Adapter, key, id string) error {
+	if leaser, ok := adapter.(LeaseAdapter); ok {
+		return leaser.ReleaseLease(ctx, key, id)
+	}
+
+	leaseMu.Lock()
+	defer leaseMu.Unlock()
+
+	now := Now()
+	held, err := getLeases(ctx, adapter, key, now)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := held[id]; !ok {
+		return nil
+	}
+	delete(held, id)
+
+	return setLeases(ctx, adapter, key, held, now)
+}
+
+// LeakyBucketAdapter is implemented by the adapters able to schedule the requests of a leaky bucket atomically.
+type LeakyBucketAdapter interface {
+	// Schedule books the next slot of the bucket at key, the bucket draining a slot of increment at a time.
+	// It returns the time the request may proceed, and false without booking it if it would wait longer than maxDelay.
+	Schedule(ctx context.Context, key string, now time.Time, increment, maxDelay time.Duration) (time.Time, bool, error)
+}
+
+// scheduleMu serializes the Schedule fallback of the adapters not implementing LeakyBucketAdapter.
+var scheduleMu sync.Mutex
+
+// Schedule books the next slot of the leaky bucket at key, atomically if the adapter is a LeakyBucketAdapter.
+// The other adapters store the time the bucket is drained with Get and Set,
+// only guarded against the concurrent calls of this process.
+func Schedule(ctx context.Context, adapter Adapter, key string, now time.Time, increment, maxDelay time.Duration) (time.Time, bool, error) {
+	if bucket, ok := adapter.(LeakyBucketAdapter); ok {
+		return bucket.Schedule(ctx, key, now, increment, maxDelay)
+	}
+
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+
+	var drained int64
+	if err := adapter.Get(ctx, key, &drained); err != nil && !errors.Is(err, ErrCacheMiss) {
+		return time.Time{}, false, err
+	}
+
+	at := now
+	if d := time.Unix(0, drained).In(now.Location()); d.After(at) {
+		at = d
+	}
+	if at.Sub(now) > maxDelay {
+		return at, false, nil
+	}
+
+	next := at.Add(increment)
+	if err := adapter.Set(ctx, key, next.UnixNano(), next.Sub(now)); err != nil {
+		return time.Time{}, false, err
+	}
+
+	return at, true, nil
+}
+
+// LogEntry is an event of a sliding log.
+type LogEntry struct {
+	At    time.Time `json:"at"`
+	Value int64     `json:"value"`
+}
+
+// LogAdapter is implemented by the adapters able to store the individual events of a sliding log.
+type LogAdapter interface {
+	// AppendLog adds the entry to the log at key, evicting the entries older than retention.
+	AppendLog(ctx context.Context, key string, entry LogEntry, retention time.Duration) error
+	// ReadLog returns the entries of the log at key logged after since and up to until, oldest first.
+	ReadLog(ctx context.Context, key string, since, until time.Time) ([]LogEntry, error)
+}
+
+// logMu serializes the log fallback of the adapters not implementing LogAdapter.
+var logMu sync.Mutex
+
+// AppendLog adds the entry to the log at key, natively if the adapter is a LogAdapter.
+// The other adapters store the log with Get and Set, only guarded against the concurrent calls of this process.
+func AppendLog(ctx context.Context, adapter Adapter, key string, entry LogEntry, retention time.Duration) error {
+	if log, ok := adapter.(LogAdapter); ok {
+		return log.AppendLog(ctx, key, entry, retention)
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	var entries []LogEntry
+	if err := adapter.Get(ctx, key, &entries); err != nil && !errors.Is(err, ErrCacheMiss) {
+		return err
+	}
+
+	return adapter.Set(ctx, key, appendLogEntry(entries, entry, retention), retention)
+}
+
+// ReadLog returns the entries of the log at key logged after since and up to until, natively if the adapter is a LogAdapter.
+func ReadLog(ctx context.Context, adapter Adapter, key string, since, until time.Time) ([]LogEntry, error) {
+	if log, ok := adapter.(LogAdapter); ok {
+		return log.ReadLog(ctx, key, since, until)
+	}
+
+	var entries []LogEntry
+	if err := adapter.Get(ctx, key, &entries); err != nil && !errors.Is(err, ErrCacheMiss) {
+		return nil, err
+	}
+
+	return LogRange(entries, since, until), nil
+}
+
+// appendLogEntry inserts the entry in the entries sorted by time, and evicts the entries older than retention.
+func appendLogEntry(entries []LogEntry, entry LogEntry, retention time.Duration) []LogEntry {
+	i := len(entries)
+	for i > 0 && entries[i-1].At.After(entry.At) {
+		i--
+	}
+	entries = append(entries[:i], append([]LogEntry{entry}, entries[i:]...)...)
+
+	evicted := 0
+	for evicted < len(entries) && !entries[evicted].At.After(entry.At.Add(-retention)) {
+		evicted++
+	}
+
+	return entries[evicted:]
+}
+
+// LogRange returns the entries, sorted by time, logged after since and up to until.
+func LogRange(entries []LogEntry, since, until time.Time) []LogEntry {
+	var inRange []LogEntry
+	for _, entry := range entries {
+		if entry.At.After(since) && !entry.At.After(until) {
+			inRange = append(inRange, entry)
+		}
+	}
+
+	return inRange
+}
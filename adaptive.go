@@ -0,0 +1,165 @@
+package limiter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Outcome is the result of a downstream call reported with Report.
+type Outcome uint8
+
+const (
+	OutcomeSuccess Outcome = iota
+	OutcomeError
+)
+
+// Adaptive configures a limit adjusted with additive-increase/multiplicative-decrease to the downstream health.
+type Adaptive struct {
+	// Duration is the window whose limit is adjusted.
+	Duration Duration
+	// Min and Max bound the adjusted limit.
+	// Max defaults to the configured limit, and is raised to Min if lower.
+	Min, Max int64
+	// Increase is added to the limit over about a full limit worth of successes, 1 by default.
+	Increase int64
+	// Decrease multiplies the limit on an error or slow outcome, 0.5 by default.
+	// It is applied at most once per Duration, so a burst of errors counts once.
+	Decrease float64
+	// Latency is the latency above which a success counts as a slow outcome, 0 disables it.
+	Latency time.Duration
+}
+
+// adaptiveLimit is the adjusted limit of a metric.
+type adaptiveLimit struct {
+	Adaptive
+	// configured is the configuration before defaults, reapplied when the limits are reloaded.
+	configured Adaptive
+
+	mu           sync.Mutex
+	current      float64
+	lastDecrease time.Time
+}
+
+func newAdaptiveLimit(a Adaptive, initial int64) *adaptiveLimit {
+	configured := a
+	if a.Increase <= 0 {
+		a.Increase = 1
+	}
+	if a.Decrease <= 0 || a.Decrease >= 1 {
+		a.Decrease = 0.5
+	}
+	if a.Max <= 0 {
+		a.Max = initial
+	}
+	if a.Max < a.Min {
+		a.Max = a.Min
+	}
+	if initial <= 0 {
+		initial = a.Max
+	}
+
+	al := &adaptiveLimit{Adaptive: a, configured: configured}
+	al.current = al.bound(float64(initial))
+
+	return al
+}
+
+// reload returns the adaptive limit for the newly configured limit, carrying over its current limit within the new bounds.
+// The adaptive limit is kept as is if its bounds are unchanged, or would leave no positive max.
+func (a *adaptiveLimit) reload(limit int64) *adaptiveLimit {
+	reloaded := newAdaptiveLimit(a.configured, limit)
+	if reloaded.Adaptive == a.Adaptive || reloaded.Max <= 0 {
+		return a
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	reloaded.current = reloaded.bound(a.current)
+	reloaded.lastDecrease = a.lastDecrease
+
+	return reloaded
+}
+
+func (a *adaptiveLimit) bound(limit float64) float64 {
+	if limit < float64(a.Min) {
+		return float64(a.Min)
+	}
+	if limit > float64(a.Max) {
+		return float64(a.Max)
+	}
+
+	return limit
+}
+
+func (a *adaptiveLimit) limit() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return int64(a.current)
+}
+
+// report adjusts the limit to the outcome, returning the new limit and whether it was decreased.
+func (a *adaptiveLimit) report(outcome Outcome, latency time.Duration, now time.Time) (int64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if outcome == OutcomeSuccess && (a.Latency == 0 || latency <= a.Latency) {
+		// the increase is spread over the successes of a full limit, like the TCP congestion window.
+		a.current = a.bound(a.current + float64(a.Increase)/a.current)
+		return int64(a.current), false
+	}
+
+	if now.Sub(a.lastDecrease) < time.Duration(a.Duration.Seconds())*time.Second {
+		return int64(a.current), false
+	}
+	a.lastDecrease = now
+	a.current = a.bound(a.current * a.Decrease)
+
+	return int64(a.current), true
+}
+
+// Report feeds the outcome of a downstream call back to the adaptive limit of the metric, see WithAdaptive.
+// An error, or a success slower than the configured latency, decreases the limit while successes increase it.
+// It is a no-op for the metrics without an adaptive limit.
+func (l *Limiter) Report(ctx context.Context, metric string, outcome Outcome, latency time.Duration) {
+	a, ok := l.table.Load().adaptive[metric]
+	if !ok {
+		return
+	}
+
+	if limit, decreased := a.report(outcome, latency, Now()); decreased {
+		l.log(ctx, slog.LevelWarn, "limiter: adaptive limit decreased", metric,
+			slog.String("window", a.Duration.String()),
+			slog.Int64("limit", limit),
+		)
+	}
+}
+
+// AdaptiveLimit returns the current adaptive limit of the metric.
+func (l *Limiter) AdaptiveLimit(metric string) (int64, bool) {
+	a, ok := l.table.Load().adaptive[metric]
+	if !ok {
+		return 0, false
+	}
+
+	return a.limit(), true
+}
+
+// adaptiveLimits returns the limits of the metric with its adaptive limit, if any.
+func (t *table) adaptiveLimits(metric string, limits Limits) Limits {
+	a, ok := t.adaptive[metric]
+	if !ok {
+		return limits
+	}
+
+	adjusted := limits.clone()
+	if adjusted == nil {
+		adjusted = make(Limits, 1)
+	}
+	adjusted[a.Duration] = a.limit()
+
+	return adjusted
+}
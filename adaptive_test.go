@@ -0,0 +1,130 @@
+package limiter_test
+
+import (
+	"time"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+var (
+	adaptiveLimits = map[string]limiter.Limits{"queries": {limiter.DurationSecond: 10}}
+	adaptiveOption = limiter.WithAdaptive("queries", limiter.Adaptive{
+		Duration: limiter.DurationSecond,
+		Min:      2,
+		Max:      12,
+		Latency:  100 * time.Millisecond,
+	})
+)
+
+func (s *LimiterSuite) TestAdaptiveIncrease() {
+	s.newLimiter(s.adapter, adaptiveLimits, adaptiveOption)
+
+	limit, ok := s.l.AdaptiveLimit("queries")
+	s.True(ok)
+	s.Equal(int64(10), limit)
+
+	// about a full limit worth of successes increases the limit by one.
+	for i := 0; i < 11; i++ {
+		s.l.Report(s.ctx, "queries", limiter.OutcomeSuccess, 10*time.Millisecond)
+	}
+	limit, _ = s.l.AdaptiveLimit("queries")
+	s.Equal(int64(11), limit)
+
+	for i := 0; i < 100; i++ {
+		s.l.Report(s.ctx, "queries", limiter.OutcomeSuccess, 10*time.Millisecond)
+	}
+	limit, _ = s.l.AdaptiveLimit("queries")
+	s.Equal(int64(12), limit, "bounded by the max")
+}
+
+func (s *LimiterSuite) TestAdaptiveDecrease() {
+	s.newLimiter(s.adapter, adaptiveLimits, adaptiveOption)
+
+	// a burst of errors within the window decreases the limit once.
+	s.l.Report(s.ctx, "queries", limiter.OutcomeError, 0)
+	s.l.Report(s.ctx, "queries", limiter.OutcomeError, 0)
+	limit, _ := s.l.AdaptiveLimit("queries")
+	s.Equal(int64(5), limit)
+
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 12, 0, time.UTC)
+	}
+	s.l.Report(s.ctx, "queries", limiter.OutcomeSuccess, time.Second)
+	limit, _ = s.l.AdaptiveLimit("queries")
+	s.Equal(int64(2), limit, "slow successes decrease the limit, bounded by the min")
+}
+
+func (s *LimiterSuite) TestAdaptiveLimitEnforced() {
+	s.newLimiter(s.adapter, adaptiveLimits, adaptiveOption)
+	s.l.Report(s.ctx, "queries", limiter.OutcomeError, 0)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"queries:acme:20240229231110"}).Return(int64(6), nil)
+
+	res, err := s.l.Evaluate(s.ctx, "queries", "acme", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.Equal(int64(5), res.Limit)
+
+	limits, _ := s.l.Limits("queries")
+	s.Equal(int64(10), limits[limiter.DurationSecond], "the configured limit is kept")
+
+	_, ok := s.l.AdaptiveLimit("unknown")
+	s.False(ok)
+}
+
+func (s *LimiterSuite) TestAdaptiveDefaultMax() {
+	s.newLimiter(s.adapter, map[string]limiter.Limits{
+		"queries": {limiter.DurationSecond: 10},
+		"writes":  {limiter.DurationMinute: 10},
+	},
+		limiter.WithAdaptive("queries", limiter.Adaptive{Duration: limiter.DurationSecond, Min: 2}),
+		limiter.WithAdaptive("writes", limiter.Adaptive{Duration: limiter.DurationSecond, Min: 50}),
+	)
+
+	// the max defaults to the configured limit.
+	for i := 0; i < 100; i++ {
+		s.l.Report(s.ctx, "queries", limiter.OutcomeSuccess, 0)
+	}
+	limit, _ := s.l.AdaptiveLimit("queries")
+	s.Equal(int64(10), limit)
+
+	// without a configured limit, the max is raised to the min.
+	limit, _ = s.l.AdaptiveLimit("writes")
+	s.Equal(int64(50), limit)
+	s.l.Report(s.ctx, "writes", limiter.OutcomeError, 0)
+	limit, _ = s.l.AdaptiveLimit("writes")
+	s.Equal(int64(50), limit)
+}
+
+func (s *LimiterSuite) TestAdaptiveRequiresMax() {
+	s.PanicsWithValue("limiter: adaptive limit of writes must have a positive max, got 0", func() {
+		limiter.New(s.adapter, map[string]limiter.Limits{"writes": {limiter.DurationMinute: 10}},
+			limiter.WithAdaptive("writes", limiter.Adaptive{Duration: limiter.DurationSecond}))
+	})
+}
+
+func (s *LimiterSuite) TestAdaptiveReload() {
+	s.newLimiter(s.adapter, adaptiveLimits,
+		limiter.WithAdaptive("queries", limiter.Adaptive{Duration: limiter.DurationSecond, Min: 2}))
+	s.l.Report(s.ctx, "queries", limiter.OutcomeError, 0)
+
+	// the reloaded limit raises the default max, the current limit is carried over.
+	s.l.Configure(map[string]limiter.MetricConfig{"queries": {Limits: limiter.Limits{limiter.DurationSecond: 20}}})
+	limit, ok := s.l.AdaptiveLimit("queries")
+	s.True(ok)
+	s.Equal(int64(5), limit)
+	for i := 0; i < 1000; i++ {
+		s.l.Report(s.ctx, "queries", limiter.OutcomeSuccess, 0)
+	}
+	limit, _ = s.l.AdaptiveLimit("queries")
+	s.Equal(int64(20), limit)
+
+	s.l.Configure(map[string]limiter.MetricConfig{"writes": {Limits: limiter.Limits{limiter.DurationSecond: 20}}})
+	_, ok = s.l.AdaptiveLimit("queries")
+	s.False(ok, "removed with its metric")
+
+	s.newLimiter(s.adapter, adaptiveLimits,
+		limiter.WithAdaptive("queries", limiter.Adaptive{Duration: limiter.DurationSecond, Min: 2}))
+	s.l.RemoveMetric("queries")
+	_, ok = s.l.AdaptiveLimit("queries")
+	s.False(ok)
+}
@@ -0,0 +1,144 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Algorithm is the strategy counting the usage of a metric.
+type Algorithm uint8
+
+const (
+	// AlgorithmSlidingWindow counts the usage in second, minute and hour buckets, it is the default algorithm.
+	AlgorithmSlidingWindow Algorithm = iota
+	// AlgorithmSlidingLog stores every recorded event with its timestamp, so the windows are exact
+	// and the events can be audited, at the cost of storing one entry per event.
+	// Its metrics are only supported by Record and Check, their records cannot be refunded so RecordReceipt fails for them.
+	AlgorithmSlidingLog
+	// AlgorithmSlidingWindowCounter approximates the window from two fixed windows of the same length:
+	// the usage of the current one plus the usage of the previous one weighted by how much it still overlaps the window.
+	// It reads two keys per check, instead of up to a hundred for a day with AlgorithmSlidingWindow,
+	// assuming the previous usage was spread evenly, so the estimate is off by at most the usage of the previous window
+	// times the larger of its weight and one minus its weight, i.e. less than the limit while the previous window was under it.
+	// Its metrics are only supported by Record and Check.
+	AlgorithmSlidingWindowCounter
+)
+
+// String returns the name of the algorithm.
+func (a Algorithm) String() string {
+	switch a {
+	case AlgorithmSlidingWindow:
+		return "sliding_window"
+	case AlgorithmSlidingLog:
+		return "sliding_log"
+	case AlgorithmSlidingWindowCounter:
+		return "sliding_window_counter"
+	default:
+		return "unknown"
+	}
+}
+
+// SetAlgorithm sets the algorithm of the metric.
+// The usage recorded with the previous algorithm is not carried over.
+func (l *Limiter) SetAlgorithm(metric string, a Algorithm) {
+	l.update(func(t *table) {
+		t.algorithms[metric] = a
+	})
+}
+
+// LogEntries returns the events of the sliding log metric recorded for the subject within the duration, oldest first.
+func (l *Limiter) LogEntries(ctx context.Context, metric, subject string, duration Duration) ([]LogEntry, error) {
+	t := l.table.Load()
+	if _, ok := t.limits[metric]; !ok {
+		return nil, ErrMetricNotFound
+	}
+	if a := t.algorithms[metric]; a != AlgorithmSlidingLog {
+		return nil, errUnsupportedAlgorithm(metric, a)
+	}
+
+	return l.logWindow(ctx, metric, subject, duration)
+}
+
+// logWindow returns the events of the sliding log of the subject within the duration.
+func (l *Limiter) logWindow(ctx context.Context, metric, subject string, duration Duration) ([]LogEntry, error) {
+	now := Now()
+	return ReadLog(ctx, l.adapter, logKey(metric, subject), now.Add(-time.Duration(duration.Seconds())*time.Second), now)
+}
+
+// appendLog records the value as an event of the sliding log of the subject,
+// kept as long as the longest window limiting it.
+func (l *Limiter) appendLog(ctx context.Context, t *table, metric, subject string, value int64) error {
+	retention := time.Duration(DurationSecond.Seconds()) * time.Second
+	for duration := range l.subjectLimits(ctx, t, metric, subject) {
+		if d := time.Duration(duration.Seconds()) * time.Second; d > retention {
+			retention = d
+		}
+	}
+
+	return AppendLog(ctx, l.adapter, logKey(metric, subject), LogEntry{At: Now(), Value: value}, retention)
+}
+
+// logUsage returns the sum of the events of the sliding log of the subject within the duration.
+func (l *Limiter) logUsage(ctx context.Context, metric, subject string, duration Duration) (int64, error) {
+	entries, err := l.logWindow(ctx, metric, subject, duration)
+	if err != nil {
+		return 0, err
+	}
+
+	var usage int64
+	for _, entry := range entries {
+		usage += entry.Value
+	}
+
+	return usage, nil
+}
+
+// counterUsage returns the usage of the subject within the duration estimated from the current and previous fixed windows.
+func (l *Limiter) counterUsage(ctx context.Context, metric, subject string, duration Duration) (int64, error) {
+	now := Now()
+	start, length, format := fixedWindow(now, duration)
+	prefix := keyPrefix(metric, subject)
+	sums, err := SumKeyGroups(ctx, l.adapter, [][]string{
+		{fmt.Sprintf("%s:%s", prefix, start.Format(format))},
+		{fmt.Sprintf("%s:%s", prefix, start.Add(-length).Format(format))},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	weight := 1 - float64(now.Sub(start))/float64(length)
+	return sums[0] + int64(float64(sums[1])*weight), nil
+}
+
+// counterKeys returns the keys of the fixed windows a value recorded at now is written to.
+// They extend the buckets of the sliding window with the day one.
+func counterKeys(metric, subject string, now time.Time) []string {
+	return append(recordKeys(metric, subject, now), fmt.Sprintf("%s:%s", keyPrefix(metric, subject), now.Format(dayFormat)))
+}
+
+// fixedWindow returns the start and length of the fixed window of the duration holding now,
+// and the format of its key suffix.
+func fixedWindow(now time.Time, duration Duration) (time.Time, time.Duration, string) {
+	y, mo, d := now.Date()
+	h, mi, sec := now.Clock()
+	switch duration {
+	case DurationMinute:
+		return time.Date(y, mo, d, h, mi, 0, 0, now.Location()), time.Minute, minuteFormat
+	case DurationHour:
+		return time.Date(y, mo, d, h, 0, 0, 0, now.Location()), time.Hour, hourFormat
+	case DurationDay:
+		return time.Date(y, mo, d, 0, 0, 0, 0, now.Location()), 24 * time.Hour, dayFormat
+	default:
+		return time.Date(y, mo, d, h, mi, sec, 0, now.Location()), time.Second, secondFormat
+	}
+}
+
+func logKey(metric, subject string) string {
+	return fmt.Sprintf("log:%s", keyPrefix(metric, subject))
+}
+
+// errUnsupportedAlgorithm reports an operation only supported by the sliding window metrics.
+func errUnsupportedAlgorithm(metric string, a Algorithm) error {
+	return fmt.Errorf("limiter: %s uses the %s algorithm, not supported by this operation", metric, a)
+}
@@ -0,0 +1,61 @@
+// Command ratelimit serves the Envoy rate limit service (RLS) gRPC API.
+//
+// The limits are read from a JSON or YAML file mapping metric names to limits per duration:
+//
+//	{
+//	  "edge.remote_address": {"second": 10, "minute": 300}
+//	}
+//
+// The file is reloaded every -reload interval, so the limits can be changed without a restart.
+// The usage is kept in memory, so every instance enforces the limits on its own.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"time"
+
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"google.golang.org/grpc"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/config"
+	"github.com/hendrywiranto/limiter/envoy"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "gRPC listen address")
+	limitsPath := flag.String("limits", "limits.json", "path of the limits JSON or YAML file")
+	reload := flag.Duration("reload", 10*time.Second, "interval between reloads of the limits file, 0 disables reloading")
+	flag.Parse()
+
+	l := limiter.New(memory.NewAdapter(), nil)
+	watcher := config.NewWatcher(l, config.FileSource(*limitsPath), config.FormatFromPath(*limitsPath))
+	if _, err := watcher.Reload(); err != nil {
+		log.Fatalf("read limits: %v", err)
+	}
+	if *reload > 0 {
+		watcher.ErrorHandler = func(err error) {
+			log.Printf("reload limits: %v", err)
+		}
+		go func() {
+			_ = watcher.Watch(context.Background(), *reload)
+		}()
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("listen: %v", err)
+	}
+
+	srv := grpc.NewServer()
+	rlsv3.RegisterRateLimitServiceServer(srv, envoy.NewServer(l))
+
+	log.Printf("serving rate limit service on %s", lis.Addr())
+	if err := srv.Serve(lis); err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Package config loads the limits of a Limiter from JSON or YAML sources and keeps them up to date.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+// Format is the encoding of a configuration source.
+type Format uint8
+
+const (
+	FormatJSON Format = iota + 1
+	FormatYAML
+)
+
+// FormatFromPath returns the format matching the file extension, YAML for .yaml and .yml files and JSON otherwise.
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// DecodeLimits decodes the limits of every metric, keyed by metric name and then by duration name:
+//
+//	api_calls:
+//	  second: 10
+//	  day: 10000
+func DecodeLimits(r io.Reader, format Format) (map[string]limiter.Limits, error) {
+	var limits map[string]limiter.Limits
+
+	switch format {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&limits); err != nil {
+			return nil, fmt.Errorf("config: decode json: %w", err)
+		}
+	case FormatYAML:
+		if err := yaml.NewDecoder(r).Decode(&limits); err != nil {
+			return nil, fmt.Errorf("config: decode yaml: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("config: unknown format %d", format)
+	}
+
+	return limits, nil
+}
@@ -0,0 +1,48 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/config"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, config.FormatYAML, config.FormatFromPath("limits.yaml"))
+	assert.Equal(t, config.FormatYAML, config.FormatFromPath("/etc/limits.YML"))
+	assert.Equal(t, config.FormatJSON, config.FormatFromPath("limits.json"))
+	assert.Equal(t, config.FormatJSON, config.FormatFromPath("limits"))
+}
+
+func TestDecodeLimitsJSON(t *testing.T) {
+	limits, err := config.DecodeLimits(strings.NewReader(`{"api_calls": {"second": 10, "day": 10000}}`), config.FormatJSON)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]limiter.Limits{
+		"api_calls": {limiter.DurationSecond: 10, limiter.DurationDay: 10000},
+	}, limits)
+}
+
+func TestDecodeLimitsYAML(t *testing.T) {
+	limits, err := config.DecodeLimits(strings.NewReader("api_calls:\n  second: 10\n  day: 10000\n"), config.FormatYAML)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]limiter.Limits{
+		"api_calls": {limiter.DurationSecond: 10, limiter.DurationDay: 10000},
+	}, limits)
+}
+
+func TestDecodeLimitsInvalid(t *testing.T) {
+	_, err := config.DecodeLimits(strings.NewReader("api_calls:\n  week: 10\n"), config.FormatYAML)
+	assert.ErrorContains(t, err, `unknown duration "week"`)
+
+	_, err = config.DecodeLimits(strings.NewReader(`{"api_calls": `), config.FormatJSON)
+	assert.ErrorContains(t, err, "config: decode json")
+
+	_, err = config.DecodeLimits(strings.NewReader(""), config.Format(0))
+	assert.ErrorContains(t, err, "unknown format")
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+const (
+	// AlgorithmSlidingWindow is the default algorithm, it sums the usage buckets covering the window.
+	AlgorithmSlidingWindow = "sliding_window"
+	// AlgorithmSlidingLog stores every event with its timestamp, see limiter.AlgorithmSlidingLog.
+	AlgorithmSlidingLog = "sliding_log"
+	// AlgorithmSlidingWindowCounter estimates the window from two fixed windows, see limiter.AlgorithmSlidingWindowCounter.
+	AlgorithmSlidingWindowCounter = "sliding_window_counter"
+)
+
+// File is the declarative configuration of a Limiter.
+// It is written in YAML, or in JSON with the same field names:
+//
+//	metrics:
+//	  - name: api_calls            # required, unique
+//	    algorithm: sliding_window  # optional, sliding_window (the default), sliding_log or sliding_window_counter
+//	    fail_policy: open          # optional, closed (the default) or open
+//	    shadow: false              # optional, evaluate without enforcing
+//	    windows:                   # required, at least one
+//	      - window: 1m             # 1s, 1m, 1h or 24h (also written 1d)
+//	        limit: 100             # required, not negative
+//	        soft_limit: 90         # optional, between 0 and limit
+//	      - window: 24h
+//	        limit: 10000
+type File struct {
+	Metrics []Metric `yaml:"metrics"`
+}
+
+// Metric is the configuration of a single metric.
+type Metric struct {
+	Name       string   `yaml:"name"`
+	Algorithm  string   `yaml:"algorithm"`
+	FailPolicy string   `yaml:"fail_policy"`
+	Shadow     bool     `yaml:"shadow"`
+	Windows    []Window `yaml:"windows"`
+
+	line int
+}
+
+// Window is the limit of a metric over an evaluation window.
+type Window struct {
+	Window    string `yaml:"window"`
+	Limit     *int64 `yaml:"limit"`
+	SoftLimit int64  `yaml:"soft_limit"`
+
+	line int
+}
+
+// Error is an invalid entry of a configuration file.
+type Error struct {
+	// Line is the line of the entry, it is zero when the configuration was not parsed from a file.
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return "config: " + e.Message
+	}
+
+	return fmt.Sprintf("config: line %d: %s", e.Line, e.Message)
+}
+
+// Parse parses and validates a YAML or JSON configuration file.
+// The returned error joins an *Error for every invalid entry.
+func Parse(r io.Reader) (*File, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var f File
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		var configErr *Error
+		var typeErr *yaml.TypeError
+		switch {
+		case errors.As(err, &configErr):
+			return nil, configErr
+		case errors.As(err, &typeErr):
+			return nil, fmt.Errorf("config: %s", strings.Join(typeErr.Errors, ", "))
+		default:
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// New parses a configuration file and returns a Limiter enforcing it.
+// opts are applied after the configuration, so they take precedence over it.
+func New(r io.Reader, adapter limiter.Adapter, opts ...limiter.Option) (*limiter.Limiter, error) {
+	f, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Limiter(adapter, opts...)
+}
+
+// Validate checks every entry of the configuration.
+// The returned error joins an *Error for every invalid entry.
+func (f *File) Validate() error {
+	var errs []error
+	names := make(map[string]int, len(f.Metrics))
+
+	for _, m := range f.Metrics {
+		if m.Name == "" {
+			errs = append(errs, &Error{Line: m.line, Message: "metric name is required"})
+			continue
+		}
+
+		if line, ok := names[m.Name]; ok {
+			errs = append(errs, &Error{Line: m.line, Message: fmt.Sprintf("metric %q is already defined at line %d", m.Name, line)})
+		}
+		names[m.Name] = m.line
+
+		errs = append(errs, m.validate()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Limiter returns a Limiter enforcing the configuration.
+// opts are applied after the configuration, so they take precedence over it.
+func (f *File) Limiter(adapter limiter.Adapter, opts ...limiter.Option) (*limiter.Limiter, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	limits := make(map[string]limiter.Limits, len(f.Metrics))
+	configOpts := make([]limiter.Option, 0, len(f.Metrics))
+	for _, m := range f.Metrics {
+		c := m.config()
+		limits[m.Name] = c.Limits
+
+		if len(c.SoftLimits) > 0 {
+			configOpts = append(configOpts, limiter.WithSoftLimits(m.Name, c.SoftLimits))
+		}
+		if c.Shadow {
+			configOpts = append(configOpts, limiter.WithShadow(m.Name))
+		}
+		if c.FailPolicy != limiter.FailClosed {
+			configOpts = append(configOpts, limiter.WithFailPolicy(m.Name, c.FailPolicy))
+		}
+		if c.Algorithm != limiter.AlgorithmSlidingWindow {
+			configOpts = append(configOpts, limiter.WithAlgorithm(m.Name, c.Algorithm))
+		}
+	}
+
+	return limiter.New(adapter, limits, append(configOpts, opts...)...), nil
+}
+
+// configs returns the configuration of every metric, for Limiter.Configure.
+// The file must be valid.
+func (f *File) configs() map[string]limiter.MetricConfig {
+	configs := make(map[string]limiter.MetricConfig, len(f.Metrics))
+	for _, m := range f.Metrics {
+		configs[m.Name] = m.config()
+	}
+
+	return configs
+}
+
+// config returns the configuration of the metric, which must be valid.
+func (m *Metric) config() limiter.MetricConfig {
+	c := limiter.MetricConfig{
+		Limits:     make(limiter.Limits, len(m.Windows)),
+		SoftLimits: make(limiter.Limits),
+		Shadow:     m.Shadow,
+	}
+	for _, w := range m.Windows {
+		duration, _ := parseWindow(w.Window)
+		c.Limits[duration] = *w.Limit
+		if w.SoftLimit > 0 {
+			c.SoftLimits[duration] = w.SoftLimit
+		}
+	}
+
+	if m.FailPolicy == limiter.FailOpen.String() {
+		c.FailPolicy = limiter.FailOpen
+	}
+	switch m.Algorithm {
+	case AlgorithmSlidingLog:
+		c.Algorithm = limiter.AlgorithmSlidingLog
+	case AlgorithmSlidingWindowCounter:
+		c.Algorithm = limiter.AlgorithmSlidingWindowCounter
+	}
+
+	return c
+}
+
+func (m *Metric) validate() []error {
+	var errs []error
+	invalid := func(line int, format string, args ...interface{}) {
+		errs = append(errs, &Error{Line: line, Message: fmt.Sprintf("metric %q: ", m.Name) + fmt.Sprintf(format, args...)})
+	}
+
+	switch m.Algorithm {
+	case "", AlgorithmSlidingWindow, AlgorithmSlidingLog, AlgorithmSlidingWindowCounter:
+	default:
+		invalid(m.line, "unknown algorithm %q, expected %s, %s or %s", m.Algorithm, AlgorithmSlidingWindow, AlgorithmSlidingLog, AlgorithmSlidingWindowCounter)
+	}
+
+	switch m.FailPolicy {
+	case "", limiter.FailClosed.String(), limiter.FailOpen.String():
+	default:
+		invalid(m.line, "unknown fail policy %q, expected closed or open", m.FailPolicy)
+	}
+
+	if len(m.Windows) == 0 {
+		invalid(m.line, "at least one window is required")
+	}
+
+	windows := make(map[limiter.Duration]int, len(m.Windows))
+	for _, w := range m.Windows {
+		duration, err := parseWindow(w.Window)
+		if err != nil {
+			invalid(w.line, "%v", err)
+		} else if line, ok := windows[duration]; ok {
+			invalid(w.line, "window %q is already defined at line %d", w.Window, line)
+		} else {
+			windows[duration] = w.line
+		}
+
+		switch {
+		case w.Limit == nil:
+			invalid(w.line, "limit is required")
+		case *w.Limit < 0:
+			invalid(w.line, "limit %d must not be negative", *w.Limit)
+		case w.SoftLimit < 0 || w.SoftLimit > *w.Limit:
+			invalid(w.line, "soft limit %d must be between 0 and the limit %d", w.SoftLimit, *w.Limit)
+		}
+	}
+
+	return errs
+}
+
+// parseWindow returns the duration of a window written as a Go duration, e.g. "1m" or "24h".
+func parseWindow(window string) (limiter.Duration, error) {
+	if window == "" {
+		return limiter.DurationUnknown, errors.New("window is required")
+	}
+	if window == "1d" {
+		return limiter.DurationDay, nil
+	}
+
+	d, err := time.ParseDuration(window)
+	if err == nil {
+		for _, duration := range limiter.Durations {
+			if d == time.Duration(duration.Seconds())*time.Second {
+				return duration, nil
+			}
+		}
+	}
+
+	return limiter.DurationUnknown, fmt.Errorf("unsupported window %q, expected 1s, 1m, 1h or 24h", window)
+}
+
+// UnmarshalYAML records the line of the metric and rejects its unknown fields.
+func (m *Metric) UnmarshalYAML(value *yaml.Node) error {
+	if err := checkFields(value, "name", "algorithm", "fail_policy", "shadow", "windows"); err != nil {
+		return err
+	}
+
+	type metric Metric
+	if err := value.Decode((*metric)(m)); err != nil {
+		return err
+	}
+	m.line = value.Line
+
+	return nil
+}
+
+// UnmarshalYAML records the line of the window and rejects its unknown fields.
+func (w *Window) UnmarshalYAML(value *yaml.Node) error {
+	if err := checkFields(value, "window", "limit", "soft_limit"); err != nil {
+		return err
+	}
+
+	type window Window
+	if err := value.Decode((*window)(w)); err != nil {
+		return err
+	}
+	w.line = value.Line
+
+	return nil
+}
+
+// checkFields returns an error for the first key of the mapping node which is not one of fields.
+func checkFields(value *yaml.Node, fields ...string) error {
+	if value.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i < len(value.Content); i += 2 {
+		key := value.Content[i]
+		known := false
+		for _, field := range fields {
+			if key.Value == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return &Error{Line: key.Line, Message: fmt.Sprintf("unknown field %q, expected one of %s", key.Value, strings.Join(fields, ", "))}
+		}
+	}
+
+	return nil
+}
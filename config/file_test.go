@@ -0,0 +1,238 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/config"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+type FileSuite struct {
+	suite.Suite
+}
+
+func TestFile(t *testing.T) {
+	suite.Run(t, new(FileSuite))
+}
+
+func (s *FileSuite) SetupTest() {
+	// mock the current time to 2024-02-29 23:11:11 UTC.
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	}
+}
+
+const validYAML = `metrics:
+  - name: api_calls
+    fail_policy: open
+    windows:
+      - window: 1m
+        limit: 100
+        soft_limit: 90
+      - window: 24h
+        limit: 10000
+  - name: exports
+    algorithm: sliding_window
+    shadow: true
+    windows:
+      - window: 1s
+        limit: 1
+`
+
+func (s *FileSuite) TestParseYAML() {
+	f, err := config.Parse(strings.NewReader(validYAML))
+
+	s.Require().NoError(err)
+	s.Require().Len(f.Metrics, 2)
+	s.Equal("api_calls", f.Metrics[0].Name)
+	s.Equal("open", f.Metrics[0].FailPolicy)
+	s.Equal([]string{"1m", "24h"}, []string{f.Metrics[0].Windows[0].Window, f.Metrics[0].Windows[1].Window})
+	s.Equal(int64(90), f.Metrics[0].Windows[0].SoftLimit)
+	s.True(f.Metrics[1].Shadow)
+}
+
+func (s *FileSuite) TestParseJSON() {
+	f, err := config.Parse(strings.NewReader(`{
+	"metrics": [
+		{"name": "api_calls", "windows": [{"window": "1h", "limit": 5}]}
+	]
+}`))
+
+	s.Require().NoError(err)
+	s.Require().Len(f.Metrics, 1)
+	s.Equal(int64(5), *f.Metrics[0].Windows[0].Limit)
+}
+
+func (s *FileSuite) TestParseInvalidEntries() {
+	_, err := config.Parse(strings.NewReader(`metrics:
+  - name: api_calls
+    algorithm: token_bucket
+    fail_policy: sometimes
+    windows:
+      - window: 2m
+        limit: 100
+      - window: 1h
+        limit: 10
+        soft_limit: 20
+  - name: api_calls
+    windows: []
+  - windows:
+      - window: 1d
+        limit: 1
+`))
+
+	s.Require().Error(err)
+	s.Equal(`config: line 2: metric "api_calls": unknown algorithm "token_bucket", expected sliding_window, sliding_log or sliding_window_counter
+config: line 2: metric "api_calls": unknown fail policy "sometimes", expected closed or open
+config: line 6: metric "api_calls": unsupported window "2m", expected 1s, 1m, 1h or 24h
+config: line 8: metric "api_calls": soft limit 20 must be between 0 and the limit 10
+config: line 11: metric "api_calls" is already defined at line 2
+config: line 11: metric "api_calls": at least one window is required
+config: line 13: metric name is required`, err.Error())
+
+	var configErr *config.Error
+	s.Require().ErrorAs(err, &configErr)
+	s.Equal(2, configErr.Line)
+}
+
+func (s *FileSuite) TestParseDuplicateWindow() {
+	_, err := config.Parse(strings.NewReader(`metrics:
+  - name: api_calls
+    windows:
+      - window: 24h
+        limit: 100
+      - window: 1d
+        limit: -1
+`))
+
+	s.Require().Error(err)
+	s.Equal(`config: line 6: metric "api_calls": window "1d" is already defined at line 4
+config: line 6: metric "api_calls": limit -1 must not be negative`, err.Error())
+}
+
+func (s *FileSuite) TestParseMissingLimit() {
+	_, err := config.Parse(strings.NewReader(`metrics:
+  - name: api_calls
+    windows:
+      - window: 1m
+        soft_limit: 10
+      - window: 1h
+        limit: 0
+`))
+
+	s.Require().Error(err)
+	s.Equal(`config: line 4: metric "api_calls": limit is required`, err.Error())
+}
+
+func (s *FileSuite) TestParseUnknownField() {
+	_, err := config.Parse(strings.NewReader(`metrics:
+  - name: api_calls
+    windows:
+      - window: 1m
+        limits: 100
+`))
+
+	s.Require().Error(err)
+	s.Equal(`config: line 5: unknown field "limits", expected one of window, limit, soft_limit`, err.Error())
+}
+
+func (s *FileSuite) TestParseTypeError() {
+	_, err := config.Parse(strings.NewReader(`metrics:
+  - name: api_calls
+    windows:
+      - window: 1m
+        limit: many
+`))
+
+	s.Require().Error(err)
+	s.Contains(err.Error(), "config: line 5: cannot unmarshal !!str `many` into int64")
+}
+
+func (s *FileSuite) TestNew() {
+	adapter := memory.NewAdapter()
+	l, err := config.New(strings.NewReader(validYAML), adapter)
+	s.Require().NoError(err)
+
+	limits, ok := l.Limits("api_calls")
+	s.True(ok)
+	s.Equal(limiter.Limits{limiter.DurationMinute: 100, limiter.DurationDay: 10000}, limits)
+
+	ctx := context.Background()
+	s.Require().NoError(adapter.IncrBy(ctx, "api_calls:user1:20240229231110", 95))
+	res, err := l.Evaluate(ctx, "api_calls", "user1", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal(limiter.StatusWarning, res.Status)
+
+	s.Require().NoError(adapter.IncrBy(ctx, "exports:user1:20240229231110", 2))
+	res, err = l.Evaluate(ctx, "exports", "user1", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.True(res.Allowed())
+}
+
+func (s *FileSuite) TestLimiterFailOpen() {
+	f, err := config.Parse(strings.NewReader(validYAML))
+	s.Require().NoError(err)
+
+	l, err := f.Limiter(failingAdapter{})
+	s.Require().NoError(err)
+
+	res, err := l.Evaluate(context.Background(), "api_calls", "user1", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.True(res.Fallback)
+
+	_, err = l.Evaluate(context.Background(), "exports", "user1", limiter.DurationSecond)
+	s.Error(err)
+}
+
+func (s *FileSuite) TestLimiterSlidingLog() {
+	l, err := config.New(strings.NewReader(`metrics:
+  - name: audit
+    algorithm: sliding_log
+    windows:
+      - window: 1m
+        limit: 1
+`), memory.NewAdapter())
+	s.Require().NoError(err)
+
+	ctx := context.Background()
+	s.Require().NoError(l.RecordFor(ctx, "audit", "user1", 2))
+	entries, err := l.LogEntries(ctx, "audit", "user1", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Len(entries, 1)
+	s.ErrorIs(l.CheckFor(ctx, "audit", "user1", limiter.DurationMinute), limiter.ErrLimitExceeded)
+}
+
+func (s *FileSuite) TestLimiterSlidingWindowCounter() {
+	adapter := memory.NewAdapter()
+	l, err := config.New(strings.NewReader(`metrics:
+  - name: exports
+    algorithm: sliding_window_counter
+    windows:
+      - window: 24h
+        limit: 100
+`), adapter)
+	s.Require().NoError(err)
+
+	// 23:11:11 into the day, the previous day is weighted 2929/86400.
+	ctx := context.Background()
+	s.Require().NoError(adapter.IncrBy(ctx, "exports:user1:20240228", 1000))
+	res, err := l.Evaluate(ctx, "exports", "user1", limiter.DurationDay)
+	s.Require().NoError(err)
+	s.Equal(int64(33), res.Usage)
+}
+
+type failingAdapter struct {
+	limiter.Adapter
+}
+
+func (failingAdapter) SumKeys(context.Context, []string) (int64, error) {
+	return 0, errors.New("unavailable")
+}
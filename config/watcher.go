@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+// Source opens the configuration, it is called on every reload.
+type Source func() (io.ReadCloser, error)
+
+// FileSource returns a Source reading the file at path.
+func FileSource(path string) Source {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+// Watcher reloads the limits of a Limiter from a Source.
+// The source is either the limits decoded by DecodeLimits, or a File with a top-level metrics list,
+// whose soft limits, shadow mode, fail policy and algorithm are reloaded along with the limits.
+// The limits are swapped atomically, in-flight checks finish with the limits they started with.
+// Reload and Watch must not be called concurrently.
+type Watcher struct {
+	limiter *limiter.Limiter
+	source  Source
+	format  Format
+
+	// ErrorHandler is called with the failed reloads of Watch, the previous limits are kept meanwhile.
+	ErrorHandler func(err error)
+
+	digest [sha256.Size]byte
+}
+
+// NewWatcher returns a new Watcher instance.
+func NewWatcher(l *limiter.Limiter, source Source, format Format) *Watcher {
+	return &Watcher{
+		limiter: l,
+		source:  source,
+		format:  format,
+	}
+}
+
+// errNoMetrics rejects the reloaded configurations removing every metric, most likely emptied by mistake.
+var errNoMetrics = errors.New("config: no metric configured")
+
+// Reload reads the source and updates the limits if it has changed since the last reload.
+// It reports whether the limits were updated, invalid configurations are rejected as a whole,
+// as are the empty ones which would remove every metric.
+func (w *Watcher) Reload() (bool, error) {
+	rc, err := w.source()
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+
+	buff, err := io.ReadAll(rc)
+	if err != nil {
+		return false, err
+	}
+
+	digest := sha256.Sum256(buff)
+	if digest == w.digest {
+		return false, nil
+	}
+
+	if isFile(buff) {
+		f, err := Parse(bytes.NewReader(buff))
+		if err != nil {
+			return false, err
+		}
+
+		configs := f.configs()
+		if len(configs) == 0 {
+			return false, errNoMetrics
+		}
+
+		w.limiter.Configure(configs)
+		w.digest = digest
+
+		return true, nil
+	}
+
+	limits, err := DecodeLimits(bytes.NewReader(buff), w.format)
+	if err != nil {
+		return false, err
+	}
+
+	if len(limits) == 0 {
+		return false, errNoMetrics
+	}
+
+	w.limiter.UpdateLimits(limits)
+	w.digest = digest
+
+	return true, nil
+}
+
+// isFile reports whether the configuration is a File, i.e. has a top-level metrics list.
+// The limits decoded by DecodeLimits are mappings, so a metric named metrics is not mistaken for it.
+func isFile(buff []byte) bool {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buff, &doc); err != nil || len(doc.Content) == 0 {
+		return false
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "metrics" {
+			return root.Content[i+1].Kind == yaml.SequenceNode
+		}
+	}
+
+	return false
+}
+
+// Watch reloads the limits every interval until ctx is done.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := w.Reload(); err != nil && w.ErrorHandler != nil {
+				w.ErrorHandler(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/config"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+type WatcherSuite struct {
+	suite.Suite
+
+	path    string
+	l       *limiter.Limiter
+	watcher *config.Watcher
+}
+
+func TestWatcher(t *testing.T) {
+	suite.Run(t, new(WatcherSuite))
+}
+
+func (s *WatcherSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "limits.yaml")
+	s.write("api_calls:\n  second: 10\n")
+
+	s.l = limiter.New(memory.NewAdapter(), nil)
+	s.watcher = config.NewWatcher(s.l, config.FileSource(s.path), config.FormatFromPath(s.path))
+}
+
+func (s *WatcherSuite) write(content string) {
+	s.Require().NoError(os.WriteFile(s.path, []byte(content), 0o600))
+}
+
+func (s *WatcherSuite) TestReload() {
+	updated, err := s.watcher.Reload()
+	s.Require().NoError(err)
+	s.True(updated)
+
+	limits, ok := s.l.Limits("api_calls")
+	s.True(ok)
+	s.Equal(limiter.Limits{limiter.DurationSecond: 10}, limits)
+}
+
+func (s *WatcherSuite) TestReloadUnchanged() {
+	_, err := s.watcher.Reload()
+	s.Require().NoError(err)
+
+	updated, err := s.watcher.Reload()
+	s.Require().NoError(err)
+	s.False(updated)
+}
+
+func (s *WatcherSuite) TestReloadInvalidKeepsLimits() {
+	_, err := s.watcher.Reload()
+	s.Require().NoError(err)
+
+	s.write("api_calls:\n  week: 10\n")
+	updated, err := s.watcher.Reload()
+	s.Require().Error(err)
+	s.False(updated)
+
+	limits, _ := s.l.Limits("api_calls")
+	s.Equal(limiter.Limits{limiter.DurationSecond: 10}, limits)
+}
+
+func (s *WatcherSuite) TestReloadEmptyKeepsLimits() {
+	_, err := s.watcher.Reload()
+	s.Require().NoError(err)
+
+	for _, content := range []string{"null\n", "{}\n", "metrics: []\n"} {
+		s.write(content)
+		updated, err := s.watcher.Reload()
+		s.ErrorContains(err, "no metric configured", content)
+		s.False(updated)
+	}
+
+	limits, _ := s.l.Limits("api_calls")
+	s.Equal(limiter.Limits{limiter.DurationSecond: 10}, limits)
+}
+
+func (s *WatcherSuite) TestReloadMissingFile() {
+	s.Require().NoError(os.Remove(s.path))
+
+	_, err := s.watcher.Reload()
+	s.ErrorIs(err, os.ErrNotExist)
+}
+
+func (s *WatcherSuite) TestWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 10)
+	s.watcher.ErrorHandler = func(err error) {
+		errs <- err
+	}
+	done := make(chan error)
+	go func() {
+		done <- s.watcher.Watch(ctx, time.Millisecond)
+	}()
+
+	s.Eventually(func() bool {
+		_, ok := s.l.Limits("api_calls")
+		return ok
+	}, time.Second, time.Millisecond)
+
+	s.write("api_calls:\n  second: 20\n")
+	s.Eventually(func() bool {
+		limits, _ := s.l.Limits("api_calls")
+		return limits[limiter.DurationSecond] == 20
+	}, time.Second, time.Millisecond)
+
+	s.write("api_calls: [")
+	s.Require().Error(<-errs)
+
+	cancel()
+	s.ErrorIs(<-done, context.Canceled)
+}
+
+func (s *WatcherSuite) TestReloadFile() {
+	s.write(`metrics:
+  - name: api_calls
+    shadow: true
+    windows:
+      - window: 1m
+        limit: 100
+        soft_limit: 90
+  - name: audit
+    algorithm: sliding_log
+    windows:
+      - window: 1h
+        limit: 5
+`)
+	updated, err := s.watcher.Reload()
+	s.Require().NoError(err)
+	s.True(updated)
+
+	ctx := context.Background()
+	res, err := s.l.Evaluate(ctx, "api_calls", "user1", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal(limiter.Result{Status: limiter.StatusOK, Limit: 100, SoftLimit: 90, Shadow: true}, res)
+	_, err = s.l.LogEntries(ctx, "audit", "user1", limiter.DurationHour)
+	s.NoError(err)
+
+	// every setting of the file is replaced, the removed metrics and settings are dropped.
+	s.write(`metrics:
+  - name: api_calls
+    windows:
+      - window: 1m
+        limit: 50
+`)
+	updated, err = s.watcher.Reload()
+	s.Require().NoError(err)
+	s.True(updated)
+
+	res, err = s.l.Evaluate(ctx, "api_calls", "user1", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal(limiter.Result{Status: limiter.StatusOK, Limit: 50}, res)
+	_, ok := s.l.Limits("audit")
+	s.False(ok)
+
+	// invalid files are rejected as a whole.
+	s.write(`metrics:
+  - name: api_calls
+    windows:
+      - window: 1m
+`)
+	_, err = s.watcher.Reload()
+	s.ErrorContains(err, "limit is required")
+	limits, _ := s.l.Limits("api_calls")
+	s.Equal(limiter.Limits{limiter.DurationMinute: 50}, limits)
+}
@@ -0,0 +1,71 @@
+package limiter_test
+
+import (
+	"errors"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+var (
+	counterLimits = map[string]limiter.Limits{"exports": {limiter.DurationMinute: 50, limiter.DurationDay: 1000}}
+	counterOption = limiter.WithAlgorithm("exports", limiter.AlgorithmSlidingWindowCounter)
+)
+
+func (s *LimiterSuite) TestSlidingWindowCounterWeightsPreviousWindow() {
+	adapter := memory.NewAdapter()
+	s.newLimiter(adapter, counterLimits, counterOption)
+	s.Require().NoError(adapter.IncrBy(s.ctx, "exports:acme:202402292310", 60))
+	s.Require().NoError(adapter.IncrBy(s.ctx, "exports:acme:20240228", 1000))
+	s.Require().NoError(s.l.RecordFor(s.ctx, "exports", "acme", 5))
+
+	// 11s into the minute, 49/60 of the previous minute still overlaps the window.
+	res, err := s.l.Evaluate(s.ctx, "exports", "acme", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal(int64(5+49), res.Usage)
+	s.Equal(limiter.StatusExceeded, res.Status)
+
+	// 23:11:11 into the day, only 2929/86400 of the previous day overlaps the window.
+	res, err = s.l.Evaluate(s.ctx, "exports", "acme", limiter.DurationDay)
+	s.Require().NoError(err)
+	s.Equal(int64(5+33), res.Usage)
+	s.Equal(limiter.StatusOK, res.Status)
+}
+
+func (s *LimiterSuite) TestSlidingWindowCounterReadsTwoKeys() {
+	s.newLimiter(s.adapter, counterLimits, counterOption)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"exports:acme:202402292311"}).Return(int64(10), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"exports:acme:202402292310"}).Return(int64(0), nil)
+
+	res, err := s.l.Evaluate(s.ctx, "exports", "acme", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal(int64(10), res.Usage)
+}
+
+func (s *LimiterSuite) TestSlidingWindowCounterRecordsDayWindow() {
+	s.newLimiter(s.adapter, counterLimits, counterOption)
+	for _, key := range []string{
+		"exports:acme:20240229231111",
+		"exports:acme:202402292311",
+		"exports:acme:2024022923",
+		"exports:acme:20240229",
+	} {
+		s.adapter.EXPECT().IncrBy(s.ctx, key, int64(2)).Return(nil)
+	}
+
+	receipt, err := s.l.RecordReceipt(s.ctx, "exports", "acme", 2)
+	s.Require().NoError(err)
+	s.Len(receipt.Increments, 4)
+}
+
+func (s *LimiterSuite) TestSlidingWindowCounterErrors() {
+	s.newLimiter(s.adapter, counterLimits, counterOption, limiter.WithFailPolicy("exports", limiter.FailOpen))
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"exports:acme:202402292311"}).Return(int64(0), errors.New("mocked error"))
+
+	res, err := s.l.Evaluate(s.ctx, "exports", "acme", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.True(res.Fallback)
+
+	_, err = s.l.RecordAll(s.ctx, "acme", limiter.Cost{Metric: "exports", Value: 1})
+	s.ErrorContains(err, "sliding_window_counter algorithm")
+}
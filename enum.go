@@ -1,5 +1,7 @@
 package limiter
 
+import "fmt"
+
 type Duration uint8
 
 const (
@@ -10,6 +12,9 @@ const (
 	DurationDay
 )
 
+// Durations lists every known duration from the shortest to the longest.
+var Durations = []Duration{DurationSecond, DurationMinute, DurationHour, DurationDay}
+
 func (d Duration) Seconds() int64 {
 	switch d {
 	case DurationSecond:
@@ -25,4 +30,42 @@ func (d Duration) Seconds() int64 {
 	}
 }
 
+// String returns the name of the duration.
+func (d Duration) String() string {
+	switch d {
+	case DurationSecond:
+		return "second"
+	case DurationMinute:
+		return "minute"
+	case DurationHour:
+		return "hour"
+	case DurationDay:
+		return "day"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	if d.Seconds() == 0 {
+		return nil, fmt.Errorf("limiter: unknown duration %d", d)
+	}
+
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// It allows Limits to be decoded from JSON objects keyed by duration name.
+func (d *Duration) UnmarshalText(text []byte) error {
+	for _, duration := range Durations {
+		if duration.String() == string(text) {
+			*d = duration
+			return nil
+		}
+	}
+
+	return fmt.Errorf("limiter: unknown duration %q", text)
+}
+
 type Limits map[Duration]int64
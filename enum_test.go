@@ -0,0 +1,36 @@
+package limiter_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+func TestDurationString(t *testing.T) {
+	assert.Equal(t, "second", limiter.DurationSecond.String())
+	assert.Equal(t, "minute", limiter.DurationMinute.String())
+	assert.Equal(t, "hour", limiter.DurationHour.String())
+	assert.Equal(t, "day", limiter.DurationDay.String())
+	assert.Equal(t, "unknown", limiter.DurationUnknown.String())
+}
+
+func TestLimitsJSON(t *testing.T) {
+	var limits map[string]limiter.Limits
+	err := json.Unmarshal([]byte(`{"metric_test": {"second": 5, "day": 300}}`), &limits)
+	require.NoError(t, err)
+	assert.Equal(t, limiter.Limits{limiter.DurationSecond: 5, limiter.DurationDay: 300}, limits["metric_test"])
+
+	buff, err := json.Marshal(limits)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"metric_test": {"second": 5, "day": 300}}`, string(buff))
+}
+
+func TestLimitsJSONUnknownDuration(t *testing.T) {
+	var limits limiter.Limits
+	err := json.Unmarshal([]byte(`{"week": 5}`), &limits)
+	assert.ErrorContains(t, err, `unknown duration "week"`)
+}
@@ -0,0 +1,137 @@
+// Package envoy implements the Envoy rate limit service (RLS) gRPC API on top of a Limiter.
+package envoy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+var units = map[limiter.Duration]rlsv3.RateLimitResponse_RateLimit_Unit{
+	limiter.DurationSecond: rlsv3.RateLimitResponse_RateLimit_SECOND,
+	limiter.DurationMinute: rlsv3.RateLimitResponse_RateLimit_MINUTE,
+	limiter.DurationHour:   rlsv3.RateLimitResponse_RateLimit_HOUR,
+	limiter.DurationDay:    rlsv3.RateLimitResponse_RateLimit_DAY,
+}
+
+// Server answers envoy.service.ratelimit.v3.RateLimitService requests using a Limiter.
+//
+// Every descriptor is translated into a metric and a subject.
+// The metric is the request domain followed by the descriptor entry keys, joined by dots,
+// e.g. domain "edge" with entries remote_address=10.0.0.1 and path=/login becomes "edge.remote_address.path".
+// The subject is the descriptor entry values joined by colons, e.g. "10.0.0.1:/login".
+// Descriptors whose metric has no limits configured are always allowed.
+type Server struct {
+	rlsv3.UnimplementedRateLimitServiceServer
+
+	limiter *limiter.Limiter
+}
+
+// NewServer returns a new Server instance.
+func NewServer(l *limiter.Limiter) *Server {
+	return &Server{limiter: l}
+}
+
+// ShouldRateLimit checks and records the hits of every descriptor against its limits.
+// Each descriptor is recorded with Limiter.RecordAll, so the current second and the hits are counted atomically,
+// and the hits of a descriptor over the limit are not recorded.
+// The metrics must use the default sliding window algorithm.
+func (s *Server) ShouldRateLimit(ctx context.Context, req *rlsv3.RateLimitRequest) (*rlsv3.RateLimitResponse, error) {
+	hits := int64(req.GetHitsAddend())
+	if hits == 0 {
+		hits = 1
+	}
+
+	resp := &rlsv3.RateLimitResponse{
+		OverallCode: rlsv3.RateLimitResponse_OK,
+		Statuses:    make([]*rlsv3.RateLimitResponse_DescriptorStatus, 0, len(req.GetDescriptors())),
+	}
+	for _, descriptor := range req.GetDescriptors() {
+		st, err := s.record(ctx, metricName(req.GetDomain(), descriptor), subjectName(descriptor), hits)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+
+		if st.GetCode() == rlsv3.RateLimitResponse_OVER_LIMIT {
+			resp.OverallCode = rlsv3.RateLimitResponse_OVER_LIMIT
+		}
+		resp.Statuses = append(resp.Statuses, st)
+	}
+
+	return resp, nil
+}
+
+// record records the hits of the subject if every configured duration of the metric has room for them.
+func (s *Server) record(ctx context.Context, metric, subject string, hits int64) (*rlsv3.RateLimitResponse_DescriptorStatus, error) {
+	st := &rlsv3.RateLimitResponse_DescriptorStatus{Code: rlsv3.RateLimitResponse_OK}
+
+	limits, ok := s.limiter.Limits(metric)
+	if !ok {
+		return st, nil
+	}
+	for _, duration := range limiter.Durations {
+		if _, ok := limits[duration]; ok {
+			st.CurrentLimit = rateLimit(limits, duration)
+			break
+		}
+	}
+
+	_, err := s.limiter.RecordAll(ctx, subject, limiter.Cost{Metric: metric, Value: hits})
+	var exceeded *limiter.ExceededError
+	switch {
+	case errors.As(err, &exceeded):
+		st.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+		st.CurrentLimit = rateLimit(limits, exceeded.Duration)
+	case errors.Is(err, limiter.ErrLimitExceeded):
+		st.Code = rlsv3.RateLimitResponse_OVER_LIMIT
+	case errors.Is(err, limiter.ErrMetricNotFound):
+	case err != nil:
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// rateLimit returns the limit of the duration, clamped to the range of the Envoy field.
+func rateLimit(limits limiter.Limits, duration limiter.Duration) *rlsv3.RateLimitResponse_RateLimit {
+	limit := limits[duration]
+	if limit > math.MaxUint32 {
+		limit = math.MaxUint32
+	}
+
+	return &rlsv3.RateLimitResponse_RateLimit{
+		RequestsPerUnit: uint32(limit),
+		Unit:            units[duration],
+	}
+}
+
+// metricName returns the metric name of the descriptor.
+func metricName(domain string, descriptor *ratelimitv3.RateLimitDescriptor) string {
+	parts := make([]string, 0, len(descriptor.GetEntries())+1)
+	if domain != "" {
+		parts = append(parts, domain)
+	}
+	for _, entry := range descriptor.GetEntries() {
+		parts = append(parts, entry.GetKey())
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// subjectName returns the subject of the descriptor.
+func subjectName(descriptor *ratelimitv3.RateLimitDescriptor) string {
+	parts := make([]string, 0, len(descriptor.GetEntries()))
+	for _, entry := range descriptor.GetEntries() {
+		parts = append(parts, entry.GetValue())
+	}
+
+	return strings.Join(parts, ":")
+}
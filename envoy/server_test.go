@@ -0,0 +1,177 @@
+package envoy_test
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	ratelimitv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	rlsv3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/envoy"
+	"github.com/hendrywiranto/limiter/memory"
+	"github.com/hendrywiranto/limiter/mock"
+)
+
+type ServerSuite struct {
+	suite.Suite
+
+	ctx    context.Context
+	limits map[string]limiter.Limits
+	srv    *grpc.Server
+	conn   *grpc.ClientConn
+	client rlsv3.RateLimitServiceClient
+}
+
+func TestServer(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
+
+func (s *ServerSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.limits = map[string]limiter.Limits{
+		"edge.remote_address": {
+			limiter.DurationSecond: 2,
+			limiter.DurationMinute: 10,
+		},
+	}
+
+	// mock the current time to 2024-02-29 23:11:11 UTC.
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	}
+
+	s.serve(memory.NewAdapter())
+}
+
+func (s *ServerSuite) TearDownTest() {
+	s.conn.Close()
+	s.srv.Stop()
+}
+
+func (s *ServerSuite) serve(adapter limiter.Adapter) {
+	if s.srv != nil {
+		s.TearDownTest()
+	}
+
+	lis := bufconn.Listen(1024 * 1024)
+	s.srv = grpc.NewServer()
+	rlsv3.RegisterRateLimitServiceServer(s.srv, envoy.NewServer(limiter.New(adapter, s.limits)))
+	go func() {
+		_ = s.srv.Serve(lis)
+	}()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Require().NoError(err)
+	s.conn = conn
+	s.client = rlsv3.NewRateLimitServiceClient(conn)
+}
+
+func (s *ServerSuite) request(value string, hits uint32) *rlsv3.RateLimitRequest {
+	return &rlsv3.RateLimitRequest{
+		Domain: "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{
+			{Entries: []*ratelimitv3.RateLimitDescriptor_Entry{{Key: "remote_address", Value: value}}},
+		},
+		HitsAddend: hits,
+	}
+}
+
+func (s *ServerSuite) TestShouldRateLimitWithinLimit() {
+	resp, err := s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.1", 0))
+
+	s.Require().NoError(err)
+	s.Equal(rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	s.Require().Len(resp.GetStatuses(), 1)
+	s.Equal(rlsv3.RateLimitResponse_OK, resp.GetStatuses()[0].GetCode())
+	s.Equal(uint32(2), resp.GetStatuses()[0].GetCurrentLimit().GetRequestsPerUnit())
+	s.Equal(rlsv3.RateLimitResponse_RateLimit_SECOND, resp.GetStatuses()[0].GetCurrentLimit().GetUnit())
+}
+
+func (s *ServerSuite) TestShouldRateLimitOverLimit() {
+	for i := 0; i < 2; i++ {
+		resp, err := s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.1", 0))
+		s.Require().NoError(err)
+		s.Equal(rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	}
+
+	// the hits of the current second are counted.
+	resp, err := s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.1", 1))
+	s.Require().NoError(err)
+	s.Equal(rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+	s.Equal(rlsv3.RateLimitResponse_RateLimit_SECOND, resp.GetStatuses()[0].GetCurrentLimit().GetUnit())
+
+	// the hits addend is counted, and the denied hits are not recorded.
+	resp, err = s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.2", 3))
+	s.Require().NoError(err)
+	s.Equal(rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+
+	resp, err = s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.2", 2))
+	s.Require().NoError(err)
+	s.Equal(rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+
+	// the second window still holds the hits of the previous second.
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 12, 0, time.UTC)
+	}
+	resp, err = s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.1", 1))
+	s.Require().NoError(err)
+	s.Equal(rlsv3.RateLimitResponse_OVER_LIMIT, resp.GetOverallCode())
+
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 13, 0, time.UTC)
+	}
+	resp, err = s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.1", 1))
+	s.Require().NoError(err)
+	s.Equal(rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+}
+
+func (s *ServerSuite) TestShouldRateLimitClampsLimit() {
+	s.limits["edge.remote_address"] = limiter.Limits{limiter.DurationDay: math.MaxUint32 + 1}
+	s.serve(memory.NewAdapter())
+
+	resp, err := s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.1", 0))
+	s.Require().NoError(err)
+	s.Equal(uint32(math.MaxUint32), resp.GetStatuses()[0].GetCurrentLimit().GetRequestsPerUnit())
+}
+
+func (s *ServerSuite) TestShouldRateLimitUnknownMetric() {
+	req := &rlsv3.RateLimitRequest{
+		Domain: "edge",
+		Descriptors: []*ratelimitv3.RateLimitDescriptor{
+			{Entries: []*ratelimitv3.RateLimitDescriptor_Entry{{Key: "path", Value: "/login"}}},
+		},
+	}
+
+	resp, err := s.client.ShouldRateLimit(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(rlsv3.RateLimitResponse_OK, resp.GetOverallCode())
+	s.Nil(resp.GetStatuses()[0].GetCurrentLimit())
+}
+
+func (s *ServerSuite) TestShouldRateLimitAdapterError() {
+	adapter := mock.NewMockAdapter(gomock.NewController(s.T()))
+	adapter.EXPECT().SumKeys(gomock.Any(), gomock.Any()).Return(int64(0), context.DeadlineExceeded)
+	s.serve(adapter)
+
+	_, err := s.client.ShouldRateLimit(s.ctx, s.request("10.0.0.1", 1))
+
+	s.Require().Error(err)
+	s.Equal(codes.Unavailable, status.Code(err))
+}
@@ -3,8 +3,13 @@ package limiter
 import "errors"
 
 var (
-	ErrCacheMiss      = errors.New("cache: key not found")
-	ErrLimitExceeded  = errors.New("limiter: limit exceeded")
-	ErrLimitNotSet    = errors.New("limiter: limit not set")
-	ErrMetricNotFound = errors.New("limiter: metric not found")
+	ErrCacheMiss         = errors.New("cache: key not found")
+	ErrHierarchyNotFound = errors.New("limiter: hierarchy not found")
+	ErrLeaseNotHeld      = errors.New("limiter: lease not held")
+	ErrLimitExceeded     = errors.New("limiter: limit exceeded")
+	ErrLimitNotSet       = errors.New("limiter: limit not set")
+	ErrMetricNotFound    = errors.New("limiter: metric not found")
+	ErrPlanNotFound      = errors.New("limiter: plan not found")
+	ErrQueueFull         = errors.New("limiter: queue full")
+	ErrQueueTimeout      = errors.New("limiter: queue timeout")
 )
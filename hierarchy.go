@@ -0,0 +1,198 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WithHierarchy configures a hierarchy limiting the same usage at several levels at once,
+// e.g. WithHierarchy("api_calls", "org_api_calls", "project_api_calls", "user_api_calls").
+// Each level is a metric with its own limits, listed from the top level down.
+func WithHierarchy(name string, levels ...string) Option {
+	return func(l *Limiter) {
+		l.hierarchies[name] = append([]string(nil), levels...)
+	}
+}
+
+// RecordHierarchy records the value at every level of the hierarchy, in a single round-trip if the adapter is a BatchAdapter.
+// subjects are the subjects of each level, from the top level down, e.g. the organization, project and user IDs.
+// The levels of the allowlisted and denylisted subjects are not recorded.
+// An adapter error is returned unless every recorded level fails open.
+func (l *Limiter) RecordHierarchy(ctx context.Context, name string, subjects []string, value int64) (err error) {
+	ctx, span := l.startSpan(ctx, "limiter.RecordHierarchy")
+	span.SetAttribute(AttributeMetric, name)
+	span.SetAttribute(AttributeSubject, strings.Join(subjects, "/"))
+	span.SetAttribute(AttributeValue, value)
+	defer func() { endSpan(span, err) }()
+
+	t := l.table.Load()
+	levels, err := l.hierarchyLevels(t, name, subjects)
+	if err != nil {
+		return err
+	}
+
+	now := Now()
+	keys := make([]string, 0, len(levels)*3)
+	var recorded []int
+	for i, metric := range levels {
+		if allowed, denied := t.listed(subjects[i]); allowed || denied {
+			continue
+		}
+		recorded = append(recorded, i)
+
+		prefix := keyPrefix(metric, subjects[i])
+		for _, format := range []string{secondFormat, minuteFormat, hourFormat} {
+			keys = append(keys, fmt.Sprintf("%s:%s", prefix, now.Format(format)))
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := IncrByKeys(ctx, l.adapter, keys, value); err != nil {
+		l.logAdapterError(ctx, OperationIncrByKeys, name, subjects[len(subjects)-1], err)
+		// the failed keys are unknown, the error is returned unless every recorded level fails open.
+		for _, i := range recorded {
+			if t.failPolicy[levels[i]] != FailOpen {
+				return err
+			}
+		}
+		for _, i := range recorded {
+			l.logFallback(ctx, OperationIncrByKeys, levels[i], subjects[i])
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// CheckHierarchy checks if any level of the hierarchy has exceeded its limit.
+// The returned ErrLimitExceeded names the exhausted level.
+func (l *Limiter) CheckHierarchy(ctx context.Context, name string, subjects []string, duration Duration) error {
+	results, err := l.EvaluateHierarchy(ctx, name, subjects, duration)
+	if err != nil {
+		return err
+	}
+
+	levels := l.hierarchies[name]
+	for i, res := range results {
+		if !res.Allowed() {
+			return fmt.Errorf("%w: %s %s", ErrLimitExceeded, levels[i], subjects[i])
+		}
+	}
+
+	return nil
+}
+
+// EvaluateHierarchy evaluates every level of the hierarchy, reading their usage in a single round-trip if the adapter is a BatchAdapter,
+// after looking up the ban of every level with a penalty.
+// The results are in the order of the levels.
+// The levels of the allowlisted and denylisted subjects are decided by the lists, without reading their usage.
+// On an adapter error, the levels failing open are reported as fallbacks, the error is returned if any level fails closed.
+func (l *Limiter) EvaluateHierarchy(ctx context.Context, name string, subjects []string, duration Duration) (results []Result, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.CheckHierarchy")
+	span.SetAttribute(AttributeMetric, name)
+	span.SetAttribute(AttributeSubject, strings.Join(subjects, "/"))
+	span.SetAttribute(AttributeDuration, duration.String())
+	defer func() { endSpan(span, err) }()
+
+	t := l.table.Load()
+	levels, err := l.hierarchyLevels(t, name, subjects)
+	if err != nil {
+		return nil, err
+	}
+
+	results = make([]Result, len(levels))
+	var (
+		groups   [][]string
+		pending  []int
+		keyCount int
+	)
+	for i, metric := range levels {
+		allowed, denied := t.listed(subjects[i])
+		var until time.Time
+		if !allowed && !denied {
+			until = l.bannedUntil(ctx, t, metric, subjects[i])
+		}
+
+		switch {
+		case denied:
+			results[i] = Result{Status: StatusExceeded, Denylisted: true}
+		case allowed:
+			results[i] = Result{Status: StatusOK, Allowlisted: true}
+		case !until.IsZero():
+			results[i] = Result{Status: StatusExceeded, BannedUntil: until}
+		default:
+			groups = append(groups, l.windowKeys(metric, subjects[i], duration))
+			pending = append(pending, i)
+			keyCount += len(groups[len(groups)-1])
+		}
+	}
+	span.SetAttribute(AttributeKeyCount, keyCount)
+
+	var sums []int64
+	if len(groups) > 0 {
+		sums, err = SumKeyGroups(ctx, l.adapter, groups)
+		if err != nil {
+			l.logAdapterError(ctx, OperationSumKeyGroups, name, subjects[len(subjects)-1], err)
+			for _, i := range pending {
+				if t.failPolicy[levels[i]] != FailOpen {
+					return nil, err
+				}
+			}
+			for _, i := range pending {
+				l.logFallback(ctx, OperationSumKeyGroups, levels[i], subjects[i])
+				results[i] = Result{Status: StatusOK, Fallback: true}
+			}
+			pending = nil
+		}
+	}
+
+	for j, i := range pending {
+		limits := l.subjectLimits(ctx, t, levels[i], subjects[i])
+		results[i], err = l.decide(ctx, t, levels[i], subjects[i], duration, limits, sums[j])
+		if err != nil {
+			return nil, err
+		}
+		if results[i].Status == StatusExceeded && !results[i].Shadow {
+			l.penalize(ctx, t, levels[i], subjects[i])
+		}
+	}
+
+	status := StatusOK
+	for _, res := range results {
+		if !res.Shadow && res.Status > status {
+			status = res.Status
+		}
+	}
+	span.SetAttribute(AttributeDecision, decision(status))
+
+	return results, nil
+}
+
+// hierarchyLevels returns the level metrics of the hierarchy, checking they match the subjects.
+func (l *Limiter) hierarchyLevels(t *table, name string, subjects []string) ([]string, error) {
+	levels, ok := l.hierarchies[name]
+	if !ok {
+		return nil, ErrHierarchyNotFound
+	}
+
+	if len(subjects) != len(levels) {
+		return nil, fmt.Errorf("limiter: hierarchy %q has %d levels, got %d subjects", name, len(levels), len(subjects))
+	}
+
+	for _, metric := range levels {
+		if _, ok := t.limits[metric]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMetricNotFound, metric)
+		}
+		if a := t.algorithms[metric]; a != AlgorithmSlidingWindow {
+			return nil, errUnsupportedAlgorithm(metric, a)
+		}
+	}
+
+	return levels, nil
+}
@@ -0,0 +1,153 @@
+package limiter_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+	"github.com/hendrywiranto/limiter/mock"
+)
+
+type batchAdapter struct {
+	*mock.MockAdapter
+	*mock.MockBatchAdapter
+}
+
+var hierarchyLimits = map[string]limiter.Limits{
+	"org_calls":     {limiter.DurationSecond: 100},
+	"project_calls": {limiter.DurationSecond: 20},
+	"user_calls":    {limiter.DurationSecond: 5},
+}
+
+var hierarchyOption = limiter.WithHierarchy("calls", "org_calls", "project_calls", "user_calls")
+
+func (s *LimiterSuite) TestRecordHierarchySingleRoundTrip() {
+	batch := mock.NewMockBatchAdapter(s.ctrl)
+	s.newLimiter(batchAdapter{MockAdapter: s.adapter, MockBatchAdapter: batch}, hierarchyLimits, hierarchyOption)
+	batch.EXPECT().IncrByKeys(s.ctx, []string{
+		"org_calls:acme:20240229231111", "org_calls:acme:202402292311", "org_calls:acme:2024022923",
+		"project_calls:web:20240229231111", "project_calls:web:202402292311", "project_calls:web:2024022923",
+		"user_calls:42:20240229231111", "user_calls:42:202402292311", "user_calls:42:2024022923",
+	}, int64(3)).Return(nil)
+
+	err := s.l.RecordHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, 3)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestCheckHierarchySingleRoundTrip() {
+	batch := mock.NewMockBatchAdapter(s.ctrl)
+	s.newLimiter(batchAdapter{MockAdapter: s.adapter, MockBatchAdapter: batch}, hierarchyLimits, hierarchyOption)
+	batch.EXPECT().SumKeyGroups(s.ctx, [][]string{
+		{"org_calls:acme:20240229231110"},
+		{"project_calls:web:20240229231110"},
+		{"user_calls:42:20240229231110"},
+	}).Return([]int64{50, 21, 1}, nil)
+
+	results, err := s.l.EvaluateHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, limiter.DurationSecond)
+	s.NoError(err)
+	s.Require().Len(results, 3)
+	s.Equal(limiter.StatusOK, results[0].Status)
+	s.Equal(limiter.StatusExceeded, results[1].Status)
+	s.Equal(limiter.StatusOK, results[2].Status)
+}
+
+func (s *LimiterSuite) TestCheckHierarchyAncestorExhausted() {
+	adapter := memory.NewAdapter()
+	s.newLimiter(adapter, hierarchyLimits, hierarchyOption)
+
+	// another user of the same project has used the project quota.
+	s.Require().NoError(adapter.IncrBy(s.ctx, "project_calls:web:20240229231110", 21))
+
+	err := s.l.CheckHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "project_calls web")
+
+	err = s.l.CheckHierarchy(s.ctx, "calls", []string{"acme", "mobile", "43"}, limiter.DurationSecond)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestRecordHierarchyFallback() {
+	s.newLimiter(s.adapter, hierarchyLimits, hierarchyOption)
+	s.adapter.EXPECT().IncrBy(s.ctx, gomock.Any(), int64(1)).Return(nil).Times(9)
+
+	err := s.l.RecordHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, 1)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestCheckHierarchyAdapterError() {
+	s.newLimiter(s.adapter, hierarchyLimits, hierarchyOption)
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), mockedErr)
+
+	err := s.l.CheckHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, limiter.DurationSecond)
+	s.ErrorIs(err, mockedErr)
+}
+
+func (s *LimiterSuite) TestHierarchyFailPolicy() {
+	mockedErr := errors.New("mocked error")
+	s.newLimiter(s.adapter, hierarchyLimits, hierarchyOption,
+		limiter.WithFailPolicy("org_calls", limiter.FailOpen),
+		limiter.WithFailPolicy("project_calls", limiter.FailOpen),
+		limiter.WithFailPolicy("user_calls", limiter.FailOpen))
+	s.adapter.EXPECT().IncrBy(s.ctx, gomock.Any(), int64(1)).Return(mockedErr)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), mockedErr)
+
+	s.NoError(s.l.RecordHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, 1))
+	results, err := s.l.EvaluateHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, limiter.DurationSecond)
+	s.Require().NoError(err)
+	for _, res := range results {
+		s.Equal(limiter.Result{Status: limiter.StatusOK, Fallback: true}, res)
+	}
+
+	// a single level failing closed fails the whole hierarchy.
+	s.newLimiter(s.adapter, hierarchyLimits, hierarchyOption, limiter.WithFailPolicy("org_calls", limiter.FailOpen))
+	s.adapter.EXPECT().IncrBy(s.ctx, gomock.Any(), int64(1)).Return(mockedErr)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), mockedErr)
+
+	s.ErrorIs(s.l.RecordHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, 1), mockedErr)
+	s.ErrorIs(s.l.CheckHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, limiter.DurationSecond), mockedErr)
+}
+
+func (s *LimiterSuite) TestHierarchyPenalty() {
+	adapter := memory.NewAdapter()
+	s.newLimiter(adapter, hierarchyLimits, hierarchyOption,
+		limiter.WithPenalty("user_calls", limiter.Penalty{Violations: 2, Window: time.Minute, Ban: time.Minute}))
+	s.Require().NoError(adapter.IncrBy(s.ctx, "user_calls:42:20240229231110", 6))
+
+	for i := 0; i < 2; i++ {
+		err := s.l.CheckHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, limiter.DurationSecond)
+		s.Require().ErrorIs(err, limiter.ErrLimitExceeded)
+	}
+
+	// the hierarchy denials count toward the ban of the level.
+	res, err := s.l.Evaluate(s.ctx, "user_calls", "42", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 11, 0, time.UTC), res.BannedUntil)
+}
+
+func (s *LimiterSuite) TestHierarchyInvalid() {
+	s.newLimiter(s.adapter, hierarchyLimits, hierarchyOption)
+
+	err := s.l.RecordHierarchy(s.ctx, "unknown", []string{"acme"}, 1)
+	s.ErrorIs(err, limiter.ErrHierarchyNotFound)
+
+	err = s.l.CheckHierarchy(s.ctx, "calls", []string{"acme", "web"}, limiter.DurationSecond)
+	s.ErrorContains(err, `hierarchy "calls" has 3 levels, got 2 subjects`)
+
+	s.l.RemoveMetric("project_calls")
+	err = s.l.CheckHierarchy(s.ctx, "calls", []string{"acme", "web", "42"}, limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrMetricNotFound)
+}
+
+func (s *LimiterSuite) TestCheckHierarchyWithoutSubject() {
+	s.newLimiter(memory.NewAdapter(), hierarchyLimits, hierarchyOption)
+	s.Require().NoError(s.l.RecordHierarchy(s.ctx, "calls", []string{"", "web", "42"}, 101))
+	s.setNow(limiter.Now().Add(time.Second))
+
+	err := s.l.CheckHierarchy(s.ctx, "calls", []string{"", "web", "42"}, limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "org_calls")
+}
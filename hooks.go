@@ -0,0 +1,115 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// EventKind is the kind of an Event.
+type EventKind uint8
+
+const (
+	// EventThreshold fires when the usage reaches a soft threshold of the limit.
+	EventThreshold EventKind = iota + 1
+	// EventLimitExceeded fires when a check is denied because the usage is over the limit.
+	EventLimitExceeded
+)
+
+// Event describes a threshold crossing of a subject.
+type Event struct {
+	Kind     EventKind
+	Metric   string
+	Subject  string
+	Duration Duration
+	Usage    int64
+	Limit    int64
+	// Threshold is the crossed fraction of the limit, it is 1 for EventLimitExceeded.
+	Threshold float64
+	// Shadow reports the metric is in shadow mode, a denial was not enforced.
+	Shadow bool
+}
+
+// Hook is called with the threshold crossings detected by the checks.
+// Hooks run synchronously within the check, so they should return quickly.
+type Hook func(ctx context.Context, event Event)
+
+// hookTracker fires the hooks at most once per event, subject and window.
+type hookTracker struct {
+	hooks      []Hook
+	thresholds map[string]map[Duration][]float64
+
+	mu sync.Mutex
+	// fired holds the end of the window, in unix seconds, each event was last fired in.
+	fired map[string]int64
+}
+
+func newHookTracker() *hookTracker {
+	return &hookTracker{
+		thresholds: make(map[string]map[Duration][]float64),
+		fired:      make(map[string]int64),
+	}
+}
+
+// observe fires the hooks for every threshold the usage has reached.
+func (t *hookTracker) observe(ctx context.Context, metric, subject string, duration Duration, res Result) {
+	if len(t.hooks) == 0 {
+		return
+	}
+
+	usage, limit := res.Usage, res.Limit
+	event := Event{
+		Metric:   metric,
+		Subject:  subject,
+		Duration: duration,
+		Usage:    usage,
+		Limit:    limit,
+		Shadow:   res.Shadow,
+	}
+
+	if res.Status == StatusExceeded {
+		event.Kind, event.Threshold = EventLimitExceeded, 1
+		t.fire(ctx, event)
+		return
+	}
+
+	for _, threshold := range t.thresholds[metric][duration] {
+		if float64(usage) >= threshold*float64(limit) {
+			event.Kind, event.Threshold = EventThreshold, threshold
+			t.fire(ctx, event)
+		}
+	}
+}
+
+func (t *hookTracker) fire(ctx context.Context, event Event) {
+	key := fmt.Sprintf("%d:%s:%s:%d:%g", event.Kind, event.Metric, event.Subject, event.Duration, event.Threshold)
+	now := Now().Unix()
+
+	t.mu.Lock()
+	if end, ok := t.fired[key]; ok && now < end {
+		t.mu.Unlock()
+		return
+	}
+	t.evict(now)
+	t.fired[key] = (now/event.Duration.Seconds() + 1) * event.Duration.Seconds()
+	t.mu.Unlock()
+
+	for _, hook := range t.hooks {
+		hook(ctx, event)
+	}
+}
+
+// evict drops the events of past windows once the tracker has grown.
+// The caller must hold the lock.
+func (t *hookTracker) evict(now int64) {
+	const maxFired = 10000
+	if len(t.fired) < maxFired {
+		return
+	}
+
+	for key, end := range t.fired {
+		if now >= end {
+			delete(t.fired, key)
+		}
+	}
+}
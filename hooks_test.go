@@ -0,0 +1,72 @@
+package limiter_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+var hookLimits = map[string]limiter.Limits{"metric_test": {limiter.DurationMinute: 10}}
+
+// hookOptions notifies the thresholds crossings and denials of the metric to a hook appending them to events.
+func hookOptions(events *[]limiter.Event) []limiter.Option {
+	return []limiter.Option{
+		limiter.WithHook(func(_ context.Context, event limiter.Event) {
+			*events = append(*events, event)
+		}),
+		limiter.WithThresholds("metric_test", limiter.DurationMinute, 0.5, 0.8),
+	}
+}
+
+func (s *LimiterSuite) TestHookThresholds() {
+	events := new([]limiter.Event)
+	s.newLimiter(s.adapter, hookLimits, hookOptions(events)...)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(4), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(5), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(8), nil)
+
+	for i := 0; i < 3; i++ {
+		s.NoError(s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationMinute))
+	}
+
+	s.Equal([]limiter.Event{
+		{Kind: limiter.EventThreshold, Metric: "metric_test", Subject: "user1", Duration: limiter.DurationMinute, Usage: 5, Limit: 10, Threshold: 0.5},
+		{Kind: limiter.EventThreshold, Metric: "metric_test", Subject: "user1", Duration: limiter.DurationMinute, Usage: 8, Limit: 10, Threshold: 0.8},
+	}, *events)
+}
+
+func (s *LimiterSuite) TestHookLimitExceededOncePerWindow() {
+	events := new([]limiter.Event)
+	s.newLimiter(s.adapter, hookLimits, hookOptions(events)...)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(11), nil).Times(3)
+
+	s.ErrorIs(s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationMinute), limiter.ErrLimitExceeded)
+	s.ErrorIs(s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationMinute), limiter.ErrLimitExceeded)
+	s.Require().Len(*events, 1)
+	s.Equal(limiter.EventLimitExceeded, (*events)[0].Kind)
+	s.InDelta(1, (*events)[0].Threshold, 0)
+
+	// the next minute starts a new window.
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 12, 0o0, 0, time.UTC)
+	}
+	s.ErrorIs(s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationMinute), limiter.ErrLimitExceeded)
+	s.Len(*events, 2)
+}
+
+func (s *LimiterSuite) TestHookPerSubject() {
+	events := new([]limiter.Event)
+	s.newLimiter(s.adapter, hookLimits, hookOptions(events)...)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(5), nil).Times(3)
+
+	s.NoError(s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationMinute))
+	s.NoError(s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationMinute))
+	s.NoError(s.l.CheckFor(s.ctx, "metric_test", "user2", limiter.DurationMinute))
+
+	s.Require().Len(*events, 2)
+	s.Equal("user1", (*events)[0].Subject)
+	s.Equal("user2", (*events)[1].Subject)
+}
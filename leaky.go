@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// LeakyBucket smooths the requests of a metric to a constant rate rather than allowing bursts up to the limits.
+type LeakyBucket struct {
+	// Rate is the number of requests drained every Per.
+	Rate int64
+	Per  time.Duration
+	// Capacity is the number of requests which may be waiting for their slot, beyond it they are denied.
+	Capacity int64
+}
+
+// interval returns the time between two requests drained by the bucket, zero if the bucket drains nothing.
+func (b LeakyBucket) interval() time.Duration {
+	if b.Rate <= 0 || b.Per <= 0 {
+		return 0
+	}
+
+	return b.Per / time.Duration(b.Rate)
+}
+
+// Schedule books a slot of the leaky bucket of the metric for the subject and returns how long to delay the request,
+// so the requests of every instance sharing the adapter proceed at the constant rate of the bucket.
+// cost is the number of slots the request takes, it must be positive.
+// It returns ErrLimitExceeded without booking a slot if the bucket is full.
+func (l *Limiter) Schedule(ctx context.Context, metric, subject string, cost int64) (delay time.Duration, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Schedule")
+	span.SetAttribute(AttributeMetric, metric)
+	span.SetAttribute(AttributeSubject, subject)
+	span.SetAttribute(AttributeValue, cost)
+	defer func() { endSpan(span, err) }()
+
+	t := l.table.Load()
+	b, ok := t.leakyBuckets[metric]
+	if !ok {
+		return 0, ErrMetricNotFound
+	}
+
+	interval := b.interval()
+	if interval <= 0 {
+		return 0, fmt.Errorf("limiter: leaky bucket of %s must drain a positive rate, got %d per %s", metric, b.Rate, b.Per)
+	}
+	if cost <= 0 {
+		return 0, fmt.Errorf("limiter: leaky bucket cost must be positive, got %d", cost)
+	}
+
+	switch allowed, denied := t.listed(subject); {
+	case denied:
+		return 0, errDenylisted()
+	case allowed:
+		return 0, nil
+	}
+
+	now := Now()
+	at, scheduled, err := Schedule(ctx, l.adapter, leakyBucketKey(metric, subject), now, interval*time.Duration(cost), interval*time.Duration(b.Capacity))
+	if err != nil {
+		l.logAdapterError(ctx, OperationSchedule, metric, subject, err)
+		if t.failPolicy[metric] == FailOpen {
+			l.logFallback(ctx, OperationSchedule, metric, subject)
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	if !scheduled {
+		span.SetAttribute(AttributeDecision, DecisionDenied)
+		l.log(ctx, slog.LevelInfo, "limiter: leaky bucket full", metric,
+			slog.String("subject", subject),
+			slog.Int64("capacity", b.Capacity),
+		)
+
+		return 0, ErrLimitExceeded
+	}
+	span.SetAttribute(AttributeDecision, DecisionAllowed)
+
+	// the adapters may store the slots at a coarser precision, e.g. microseconds with Redis,
+	// so a slot of an empty bucket may start slightly before now.
+	return max(0, at.Sub(now)), nil
+}
+
+// leakyBucketKey returns the storage key of the leaky bucket of the metric and subject.
+func leakyBucketKey(metric, subject string) string {
+	return fmt.Sprintf("leaky:%s", keyPrefix(metric, subject))
+}
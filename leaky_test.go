@@ -0,0 +1,104 @@
+package limiter_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+	"github.com/hendrywiranto/limiter/mock"
+)
+
+var leakyOption = limiter.WithLeakyBucket("partner", limiter.LeakyBucket{Rate: 10, Per: time.Second, Capacity: 3})
+
+func (s *LimiterSuite) TestLeakyBucketSmoothsBursts() {
+	s.newLimiter(memory.NewAdapter(), nil, leakyOption)
+
+	for i := 0; i < 4; i++ {
+		delay, err := s.l.Schedule(s.ctx, "partner", "", 1)
+		s.Require().NoError(err)
+		s.Equal(time.Duration(i)*100*time.Millisecond, delay)
+	}
+
+	_, err := s.l.Schedule(s.ctx, "partner", "", 1)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+
+	// the bucket has drained a second later.
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 12, 0, time.UTC))
+	delay, err := s.l.Schedule(s.ctx, "partner", "", 2)
+	s.Require().NoError(err)
+	s.Zero(delay)
+	delay, err = s.l.Schedule(s.ctx, "partner", "", 1)
+	s.Require().NoError(err)
+	s.Equal(200*time.Millisecond, delay)
+}
+
+type leakyBucketAdapter struct {
+	*mock.MockAdapter
+	*mock.MockLeakyBucketAdapter
+}
+
+func (s *LimiterSuite) TestLeakyBucketCoarseAdapter() {
+	bucket := mock.NewMockLeakyBucketAdapter(s.ctrl)
+	s.newLimiter(leakyBucketAdapter{MockAdapter: s.adapter, MockLeakyBucketAdapter: bucket}, nil, leakyOption)
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 999, time.UTC)
+	s.setNow(now)
+
+	// the slot of the empty bucket is truncated to the microsecond of now.
+	bucket.EXPECT().Schedule(s.ctx, "leaky:partner", now, 100*time.Millisecond, 300*time.Millisecond).
+		Return(now.Truncate(time.Microsecond), true, nil)
+
+	delay, err := s.l.Schedule(s.ctx, "partner", "", 1)
+	s.Require().NoError(err)
+	s.Zero(delay)
+}
+
+func (s *LimiterSuite) TestLeakyBucketPerSubject() {
+	s.newLimiter(memory.NewAdapter(), nil, leakyOption)
+
+	_, err := s.l.Schedule(s.ctx, "partner", "acme", 4)
+	s.Require().NoError(err)
+
+	delay, err := s.l.Schedule(s.ctx, "partner", "globex", 1)
+	s.Require().NoError(err)
+	s.Zero(delay)
+}
+
+func (s *LimiterSuite) TestLeakyBucketFallback() {
+	s.newLimiter(s.adapter, nil, leakyOption)
+	drained := limiter.Now().Add(100 * time.Millisecond)
+	s.adapter.EXPECT().Get(s.ctx, "leaky:partner:acme", gomock.Any()).Return(limiter.ErrCacheMiss)
+	s.adapter.EXPECT().Set(s.ctx, "leaky:partner:acme", drained.UnixNano(), 100*time.Millisecond).Return(nil)
+
+	delay, err := s.l.Schedule(s.ctx, "partner", "acme", 1)
+	s.Require().NoError(err)
+	s.Zero(delay)
+}
+
+func (s *LimiterSuite) TestLeakyBucketErrors() {
+	s.newLimiter(s.adapter, nil, leakyOption, limiter.WithFailPolicy("partner", limiter.FailOpen))
+
+	_, err := s.l.Schedule(s.ctx, "unknown", "acme", 1)
+	s.ErrorIs(err, limiter.ErrMetricNotFound)
+
+	s.adapter.EXPECT().Get(s.ctx, "leaky:partner:acme", gomock.Any()).Return(errors.New("mocked error"))
+	delay, err := s.l.Schedule(s.ctx, "partner", "acme", 1)
+	s.NoError(err)
+	s.Zero(delay)
+}
+
+func (s *LimiterSuite) TestLeakyBucketInvalid() {
+	s.newLimiter(s.adapter, nil, leakyOption,
+		limiter.WithLeakyBucket("no_rate", limiter.LeakyBucket{Per: time.Second, Capacity: 3}),
+		limiter.WithLeakyBucket("no_period", limiter.LeakyBucket{Rate: 10, Capacity: 3}))
+
+	// invalid buckets and costs are rejected before any adapter call.
+	_, err := s.l.Schedule(s.ctx, "no_rate", "acme", 1)
+	s.ErrorContains(err, "leaky bucket of no_rate must drain a positive rate, got 0 per 1s")
+	_, err = s.l.Schedule(s.ctx, "no_period", "acme", 1)
+	s.ErrorContains(err, "leaky bucket of no_period must drain a positive rate")
+	_, err = s.l.Schedule(s.ctx, "partner", "acme", 0)
+	s.ErrorContains(err, "cost must be positive, got 0")
+}
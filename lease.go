@@ -0,0 +1,155 @@
+package limiter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Concurrency is the number of leases a subject may hold at once and how long a lease lasts unless renewed.
+type Concurrency struct {
+	Limit int64
+	TTL   time.Duration
+}
+
+// Lease is a slot of a concurrency limit held by a subject until it is released or expires.
+type Lease struct {
+	Metric    string
+	Subject   string
+	ID        string
+	ExpiresAt time.Time
+	// Bypassed reports the lease was granted without taking a slot,
+	// to an allowlisted subject, by the shadow mode or a fail open fallback.
+	// Renewing and releasing it never call the adapter.
+	Bypassed bool
+}
+
+// Acquire takes a slot of the concurrency limit of the metric for the subject.
+// It returns ErrLimitExceeded if the subject already holds as many unexpired leases as the limit.
+// The lease must be released with Release once done, or renewed with Renew before it expires.
+func (l *Limiter) Acquire(ctx context.Context, metric, subject string) (lease Lease, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Acquire")
+	span.SetAttribute(AttributeMetric, metric)
+	span.SetAttribute(AttributeSubject, subject)
+	defer func() { endSpan(span, err) }()
+
+	t := l.table.Load()
+	c, ok := t.concurrency[metric]
+	if !ok {
+		return Lease{}, ErrMetricNotFound
+	}
+
+	id, err := newLeaseID()
+	if err != nil {
+		return Lease{}, err
+	}
+
+	now := Now()
+	lease = Lease{Metric: metric, Subject: subject, ID: id, ExpiresAt: now.Add(c.TTL)}
+	switch allowed, denied := t.listed(subject); {
+	case denied:
+		return Lease{}, errDenylisted()
+	case allowed:
+		lease.Bypassed = true
+		return lease, nil
+	}
+	acquired, err := AcquireLease(ctx, l.adapter, leaseKey(metric, subject), id, c.Limit, now, c.TTL)
+	if err != nil {
+		l.logAdapterError(ctx, OperationAcquireLease, metric, subject, err)
+		if t.failPolicy[metric] == FailOpen {
+			l.logFallback(ctx, OperationAcquireLease, metric, subject)
+			lease.Bypassed = true
+			return lease, nil
+		}
+
+		return Lease{}, err
+	}
+
+	span.SetAttribute(AttributeShadow, t.shadow[metric])
+	if !acquired {
+		span.SetAttribute(AttributeDecision, DecisionDenied)
+		l.log(ctx, slog.LevelInfo, "limiter: concurrency limit exceeded", metric,
+			slog.String("subject", subject),
+			slog.Int64("limit", c.Limit),
+			slog.Bool("shadow", t.shadow[metric]),
+		)
+		if t.shadow[metric] {
+			lease.Bypassed = true
+			return lease, nil
+		}
+
+		return Lease{}, ErrLimitExceeded
+	}
+	span.SetAttribute(AttributeDecision, DecisionAllowed)
+
+	return lease, nil
+}
+
+// Renew extends the lease by the TTL of its metric.
+// It returns ErrLeaseNotHeld if the lease has expired or been released, a bypassed lease is always extended.
+func (l *Limiter) Renew(ctx context.Context, lease Lease) (_ Lease, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Renew")
+	span.SetAttribute(AttributeMetric, lease.Metric)
+	span.SetAttribute(AttributeSubject, lease.Subject)
+	defer func() { endSpan(span, err) }()
+
+	c, ok := l.table.Load().concurrency[lease.Metric]
+	if !ok {
+		return Lease{}, ErrMetricNotFound
+	}
+
+	now := Now()
+	if lease.Bypassed {
+		lease.ExpiresAt = now.Add(c.TTL)
+		return lease, nil
+	}
+
+	renewed, err := RenewLease(ctx, l.adapter, leaseKey(lease.Metric, lease.Subject), lease.ID, now, c.TTL)
+	if err != nil {
+		l.logAdapterError(ctx, OperationRenewLease, lease.Metric, lease.Subject, err)
+		return Lease{}, err
+	}
+
+	if !renewed {
+		return Lease{}, ErrLeaseNotHeld
+	}
+	lease.ExpiresAt = now.Add(c.TTL)
+
+	return lease, nil
+}
+
+// Release frees the slot held by the lease, releasing an expired lease is a no-op.
+func (l *Limiter) Release(ctx context.Context, lease Lease) (err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Release")
+	span.SetAttribute(AttributeMetric, lease.Metric)
+	span.SetAttribute(AttributeSubject, lease.Subject)
+	defer func() { endSpan(span, err) }()
+
+	if lease.Bypassed {
+		return nil
+	}
+
+	if err := ReleaseLease(ctx, l.adapter, leaseKey(lease.Metric, lease.Subject), lease.ID); err != nil {
+		l.logAdapterError(ctx, OperationReleaseLease, lease.Metric, lease.Subject, err)
+		return err
+	}
+
+	return nil
+}
+
+// leaseKey returns the storage key of the leases of the metric and subject.
+func leaseKey(metric, subject string) string {
+	return fmt.Sprintf("lease:%s:%s", metric, subject)
+}
+
+func newLeaseID() (string, error) {
+	buff := make([]byte, 16)
+	if _, err := rand.Read(buff); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buff), nil
+}
@@ -0,0 +1,135 @@
+package limiter_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+	"github.com/hendrywiranto/limiter/mock"
+)
+
+type leaseAdapter struct {
+	*mock.MockAdapter
+	*mock.MockLeaseAdapter
+}
+
+var concurrencyOption = limiter.WithConcurrency("exports", 2, time.Minute)
+
+func (s *LimiterSuite) TestAcquireRelease() {
+	s.newLimiter(memory.NewAdapter(), nil, concurrencyOption)
+
+	first, err := s.l.Acquire(s.ctx, "exports", "acme")
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 11, 0, time.UTC), first.ExpiresAt)
+	_, err = s.l.Acquire(s.ctx, "exports", "acme")
+	s.Require().NoError(err)
+
+	_, err = s.l.Acquire(s.ctx, "exports", "acme")
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	_, err = s.l.Acquire(s.ctx, "exports", "globex")
+	s.NoError(err)
+
+	s.Require().NoError(s.l.Release(s.ctx, first))
+	_, err = s.l.Acquire(s.ctx, "exports", "acme")
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestLeaseExpiry() {
+	s.newLimiter(memory.NewAdapter(), nil, concurrencyOption)
+
+	crashed, err := s.l.Acquire(s.ctx, "exports", "acme")
+	s.Require().NoError(err)
+	renewed, err := s.l.Acquire(s.ctx, "exports", "acme")
+	s.Require().NoError(err)
+
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 41, 0, time.UTC)
+	}
+	renewed, err = s.l.Renew(s.ctx, renewed)
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 41, 0, time.UTC), renewed.ExpiresAt)
+
+	// the crashed holder has not renewed its lease, its slot is freed once it expires.
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 12, 11, 0, time.UTC)
+	}
+	_, err = s.l.Renew(s.ctx, crashed)
+	s.ErrorIs(err, limiter.ErrLeaseNotHeld)
+
+	_, err = s.l.Acquire(s.ctx, "exports", "acme")
+	s.NoError(err)
+	_, err = s.l.Acquire(s.ctx, "exports", "acme")
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestAcquireLeaseAdapter() {
+	leaser := mock.NewMockLeaseAdapter(s.ctrl)
+	s.newLimiter(leaseAdapter{MockAdapter: s.adapter, MockLeaseAdapter: leaser}, nil, concurrencyOption)
+	leaser.EXPECT().AcquireLease(s.ctx, "lease:exports:acme", gomock.Len(32), int64(2), limiter.Now(), time.Minute).Return(false, nil)
+
+	_, err := s.l.Acquire(s.ctx, "exports", "acme")
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestAcquireFallback() {
+	s.newLimiter(s.adapter, nil, concurrencyOption)
+	s.adapter.EXPECT().Get(s.ctx, "lease:exports:acme", gomock.Any()).Return(limiter.ErrCacheMiss)
+	s.adapter.EXPECT().Set(s.ctx, "lease:exports:acme", gomock.Len(1), time.Minute).Return(nil)
+
+	_, err := s.l.Acquire(s.ctx, "exports", "acme")
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestAcquireShadow() {
+	s.newLimiter(memory.NewAdapter(), nil, concurrencyOption, limiter.WithShadow("exports"))
+
+	var lease limiter.Lease
+	for i := 0; i < 3; i++ {
+		var err error
+		lease, err = s.l.Acquire(s.ctx, "exports", "acme")
+		s.NoError(err)
+	}
+
+	// the lease over the limit was never stored, it is renewed and released without the adapter.
+	s.True(lease.Bypassed)
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 41, 0, time.UTC))
+	lease, err := s.l.Renew(s.ctx, lease)
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 41, 0, time.UTC), lease.ExpiresAt)
+	s.NoError(s.l.Release(s.ctx, lease))
+}
+
+func (s *LimiterSuite) TestAcquireFailOpen() {
+	s.newLimiter(s.adapter, nil, concurrencyOption, limiter.WithFailPolicy("exports", limiter.FailOpen))
+	s.adapter.EXPECT().Get(s.ctx, "lease:exports:acme", gomock.Any()).Return(errors.New("mocked error"))
+
+	lease, err := s.l.Acquire(s.ctx, "exports", "acme")
+	s.Require().NoError(err)
+	s.True(lease.Bypassed)
+
+	// the adapter has recovered, but the lease was never stored.
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 41, 0, time.UTC))
+	lease, err = s.l.Renew(s.ctx, lease)
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 41, 0, time.UTC), lease.ExpiresAt)
+	s.NoError(s.l.Release(s.ctx, lease))
+}
+
+func (s *LimiterSuite) TestAcquireErrors() {
+	s.newLimiter(s.adapter, nil, concurrencyOption)
+
+	_, err := s.l.Acquire(s.ctx, "unknown", "acme")
+	s.ErrorIs(err, limiter.ErrMetricNotFound)
+
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().Get(s.ctx, "lease:exports:acme", gomock.Any()).Return(mockedErr)
+	_, err = s.l.Acquire(s.ctx, "exports", "acme")
+	s.ErrorIs(err, mockedErr)
+
+	s.l.RemoveMetric("exports")
+	_, err = s.l.Acquire(s.ctx, "exports", "acme")
+	s.ErrorIs(err, limiter.ErrMetricNotFound)
+}
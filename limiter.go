@@ -3,6 +3,9 @@ package limiter
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -10,6 +13,7 @@ const (
 	secondFormat = "20060102150405"
 	minuteFormat = "200601021504"
 	hourFormat   = "2006010215"
+	dayFormat    = "20060102"
 )
 
 // Now returns the current time.
@@ -18,60 +22,262 @@ var Now = time.Now
 
 type Limiter struct {
 	adapter Adapter
-	limits  map[string]Limits
+	metrics Metrics
+	tracer  Tracer
+	logger  *slog.Logger
+	sampler *logSampler
+	hooks   *hookTracker
+
+	overrides   *overrideCache
+	hierarchies map[string][]string
+
+	// mu serializes the table updates, the checks load the table without locking.
+	mu    sync.Mutex
+	table atomic.Pointer[table]
 }
 
 // New returns a new Limiter instance.
 // adapter is the storage adapter.
 // limits is a map of metric name and evaluation duration with its limits.
-func New(adapter Adapter, limits map[string]Limits) *Limiter {
-	return &Limiter{
+// opts are the optional Limiter configurations.
+func New(adapter Adapter, limits map[string]Limits, opts ...Option) *Limiter {
+	l := &Limiter{
 		adapter: adapter,
-		limits:  limits,
+		hooks:   newHookTracker(),
+
+		hierarchies: make(map[string][]string),
+	}
+	// the options may configure the table in place as the limiter is not shared yet.
+	l.table.Store(newTable(limits))
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if l.metrics != nil {
+		l.adapter = &observedAdapter{Adapter: l.adapter, metrics: l.metrics}
 	}
+
+	return l
 }
 
 // Record records the metric value.
 func (l *Limiter) Record(ctx context.Context, metric string, value int64) error {
-	if _, ok := l.limits[metric]; !ok {
-		return ErrMetricNotFound
+	return l.RecordFor(ctx, metric, "", value)
+}
+
+// RecordFor records the metric value for the given subject.
+// subject is the identifier being limited, e.g. a user ID or an IP address.
+func (l *Limiter) RecordFor(ctx context.Context, metric, subject string, value int64) error {
+	_, err := l.record(ctx, metric, subject, value, false)
+	return err
+}
+
+// RecordReceipt records the metric value for the given subject like RecordFor,
+// and returns the receipt to give the value back with Refund.
+// On error, the receipt holds the increments applied before the failure.
+// The sliding log metrics cannot be refunded, their records fail without recording anything.
+func (l *Limiter) RecordReceipt(ctx context.Context, metric, subject string, value int64) (Receipt, error) {
+	return l.record(ctx, metric, subject, value, true)
+}
+
+// record records the metric value for the given subject, failing for the sliding log metrics if refundable.
+func (l *Limiter) record(ctx context.Context, metric, subject string, value int64, refundable bool) (receipt Receipt, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Record")
+	span.SetAttribute(AttributeMetric, metric)
+	span.SetAttribute(AttributeSubject, subject)
+	span.SetAttribute(AttributeValue, value)
+	defer func() { endSpan(span, err) }()
+
+	t := l.table.Load()
+	if _, ok := t.limits[metric]; !ok {
+		return Receipt{}, ErrMetricNotFound
+	}
+	if refundable && t.algorithms[metric] == AlgorithmSlidingLog {
+		return Receipt{}, errUnsupportedAlgorithm(metric, AlgorithmSlidingLog)
 	}
 
-	now := Now()
-	if err := l.adapter.IncrBy(ctx, fmt.Sprintf("%s:%s", metric, now.Format(secondFormat)), value); err != nil {
-		return err
+	// the usage of the listed subjects is never checked, it is not recorded either.
+	if allowed, denied := t.listed(subject); allowed || denied {
+		return Receipt{}, nil
 	}
-	if err := l.adapter.IncrBy(ctx, fmt.Sprintf("%s:%s", metric, now.Format(minuteFormat)), value); err != nil {
-		return err
+
+	if t.algorithms[metric] == AlgorithmSlidingLog {
+		if err := l.appendLog(ctx, t, metric, subject, value); err != nil {
+			l.logAdapterError(ctx, OperationAppendLog, metric, subject, err)
+			if t.failPolicy[metric] == FailOpen {
+				l.logFallback(ctx, OperationAppendLog, metric, subject)
+				return Receipt{}, nil
+			}
+
+			return Receipt{}, err
+		}
+
+		return Receipt{}, nil
 	}
-	if err := l.adapter.IncrBy(ctx, fmt.Sprintf("%s:%s", metric, now.Format(hourFormat)), value); err != nil {
-		return err
+
+	now := Now()
+	keys := recordKeys(metric, subject, now)
+	if t.algorithms[metric] == AlgorithmSlidingWindowCounter {
+		keys = counterKeys(metric, subject, now)
+	}
+	for _, key := range keys {
+		if err := l.adapter.IncrBy(ctx, key, value); err != nil {
+			l.logAdapterError(ctx, OperationIncrBy, metric, subject, err)
+			if t.failPolicy[metric] == FailOpen {
+				l.logFallback(ctx, OperationIncrBy, metric, subject)
+				return receipt, nil
+			}
+
+			return receipt, err
+		}
+		receipt.Increments = append(receipt.Increments, Increment{Key: key, Value: value})
 	}
 
-	return nil
+	return receipt, nil
 }
 
 // Check checks if the metric has exceeded the limit.
 func (l *Limiter) Check(ctx context.Context, metric string, duration Duration) error {
-	if _, ok := l.limits[metric]; !ok {
-		return ErrMetricNotFound
-	}
+	return l.CheckFor(ctx, metric, "", duration)
+}
 
-	keys := l.GenerateKeys(duration)
-	sum, err := l.adapter.SumKeys(ctx, keys)
+// CheckFor checks if the metric has exceeded the limit for the given subject.
+func (l *Limiter) CheckFor(ctx context.Context, metric, subject string, duration Duration) error {
+	res, err := l.Evaluate(ctx, metric, subject, duration)
 	if err != nil {
 		return err
 	}
 
-	if _, ok := l.limits[metric][duration]; !ok {
-		return ErrLimitNotSet
+	return res.err()
+}
+
+// Evaluate checks the usage of the subject against the soft and hard limits of the metric.
+// Unlike CheckFor, going over the hard limit is reported by the result status rather than an error.
+func (l *Limiter) Evaluate(ctx context.Context, metric, subject string, duration Duration) (Result, error) {
+	return l.evaluate(ctx, metric, subject, duration, 0, false)
+}
+
+// evaluate checks the usage of the subject against the limits of the metric,
+// scaled down to the share of the priority if prioritized.
+func (l *Limiter) evaluate(ctx context.Context, metric, subject string, duration Duration, priority Priority, prioritized bool) (res Result, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Check")
+	span.SetAttribute(AttributeMetric, metric)
+	span.SetAttribute(AttributeSubject, subject)
+	span.SetAttribute(AttributeDuration, duration.String())
+	defer func() { endSpan(span, err) }()
+
+	t := l.table.Load()
+	if _, ok := t.limits[metric]; !ok {
+		return Result{}, ErrMetricNotFound
+	}
+
+	switch allowed, denied := t.listed(subject); {
+	case denied:
+		span.SetAttribute(AttributeDecision, DecisionDenied)
+		if l.metrics != nil {
+			l.metrics.ObserveDecision(metric, duration, false, false)
+		}
+
+		return Result{Status: StatusExceeded, Denylisted: true}, nil
+	case allowed:
+		span.SetAttribute(AttributeDecision, DecisionAllowed)
+		return Result{Status: StatusOK, Allowlisted: true}, nil
 	}
 
-	if sum > l.limits[metric][duration] {
-		return ErrLimitExceeded
+	if until := l.bannedUntil(ctx, t, metric, subject); !until.IsZero() {
+		span.SetAttribute(AttributeDecision, DecisionDenied)
+		if l.metrics != nil {
+			l.metrics.ObserveDecision(metric, duration, false, false)
+		}
+
+		return Result{Status: StatusExceeded, BannedUntil: until}, nil
 	}
 
-	return nil
+	limits := l.subjectLimits(ctx, t, metric, subject)
+	if prioritized {
+		span.SetAttribute(AttributePriority, int(priority))
+		limits = t.priorityLimits(metric, priority, limits)
+	}
+	sum, operation, err := l.usage(ctx, t, metric, subject, duration, span)
+	if err != nil {
+		l.logAdapterError(ctx, operation, metric, subject, err)
+		if t.failPolicy[metric] == FailOpen {
+			l.logFallback(ctx, operation, metric, subject)
+			return Result{Status: StatusOK, Fallback: true}, nil
+		}
+
+		return Result{}, err
+	}
+
+	res, err = l.decide(ctx, t, metric, subject, duration, limits, sum)
+	if err != nil {
+		return Result{}, err
+	}
+
+	span.SetAttribute(AttributeDecision, decision(res.Status))
+	span.SetAttribute(AttributeShadow, res.Shadow)
+	if res.Status == StatusExceeded && !res.Shadow {
+		l.penalize(ctx, t, metric, subject)
+	}
+
+	return res, nil
+}
+
+// usage returns the usage of the subject within the duration, counted by the algorithm of the metric,
+// and the adapter operation it was read with.
+func (l *Limiter) usage(ctx context.Context, t *table, metric, subject string, duration Duration, span Span) (int64, string, error) {
+	if t.algorithms[metric] == AlgorithmSlidingLog {
+		sum, err := l.logUsage(ctx, metric, subject, duration)
+		return sum, OperationReadLog, err
+	}
+	if t.algorithms[metric] == AlgorithmSlidingWindowCounter {
+		span.SetAttribute(AttributeKeyCount, 2)
+		sum, err := l.counterUsage(ctx, metric, subject, duration)
+		return sum, OperationSumKeyGroups, err
+	}
+
+	keys := l.windowKeys(metric, subject, duration)
+	span.SetAttribute(AttributeKeyCount, len(keys))
+	sum, err := l.adapter.SumKeys(ctx, keys)
+
+	return sum, OperationSumKeys, err
+}
+
+// decide evaluates the usage of the subject against its limits and reports the outcome
+// to the metrics, hooks and logs.
+func (l *Limiter) decide(ctx context.Context, t *table, metric, subject string, duration Duration, limits Limits, usage int64) (Result, error) {
+	limit, ok := limits[duration]
+	if !ok {
+		return Result{}, ErrLimitNotSet
+	}
+
+	res := Result{
+		Status:    StatusOK,
+		Usage:     usage,
+		Limit:     limit,
+		SoftLimit: t.softLimits[metric][duration],
+		Shadow:    t.shadow[metric],
+	}
+	switch {
+	case usage > limit:
+		res.Status = StatusExceeded
+	case res.SoftLimit > 0 && usage > res.SoftLimit:
+		res.Status = StatusWarning
+	}
+
+	if l.metrics != nil {
+		l.metrics.ObserveDecision(metric, duration, res.Status != StatusExceeded, res.Shadow)
+	}
+	l.hooks.observe(ctx, metric, subject, duration, res)
+
+	switch res.Status {
+	case StatusExceeded:
+		l.logDenied(ctx, metric, subject, duration, res)
+	case StatusWarning:
+		l.logWarning(ctx, metric, subject, duration, res)
+	}
+
+	return res, nil
 }
 
 // GenerateKeys generates the keys for the given duration.
@@ -125,3 +331,34 @@ func (l *Limiter) GenerateKeys(duration Duration) []string {
 
 	return keys
 }
+
+// windowKeys generates the keys of the subject for the given duration,
+// prefixed with the metric like the keys written by recordKeys, even without a subject.
+func (l *Limiter) windowKeys(metric, subject string, duration Duration) []string {
+	keys := l.GenerateKeys(duration)
+	prefix := keyPrefix(metric, subject)
+	for i, key := range keys {
+		keys[i] = fmt.Sprintf("%s:%s", prefix, key)
+	}
+
+	return keys
+}
+
+// recordKeys returns the keys of the second, minute and hour buckets a value recorded at now is written to.
+func recordKeys(metric, subject string, now time.Time) []string {
+	prefix := keyPrefix(metric, subject)
+	return []string{
+		fmt.Sprintf("%s:%s", prefix, now.Format(secondFormat)),
+		fmt.Sprintf("%s:%s", prefix, now.Format(minuteFormat)),
+		fmt.Sprintf("%s:%s", prefix, now.Format(hourFormat)),
+	}
+}
+
+// keyPrefix returns the storage key prefix of the metric and subject.
+func keyPrefix(metric, subject string) string {
+	if subject == "" {
+		return metric
+	}
+
+	return fmt.Sprintf("%s:%s", metric, subject)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
 	"github.com/hendrywiranto/limiter/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -46,6 +47,11 @@ func TestLimiter(t *testing.T) {
 	suite.Run(t, new(LimiterSuite))
 }
 
+// newLimiter replaces the limiter of the test with one storing its usage in adapter, configured with limits and opts.
+func (s *LimiterSuite) newLimiter(adapter limiter.Adapter, limits map[string]limiter.Limits, opts ...limiter.Option) {
+	s.l = limiter.New(adapter, limits, opts...)
+}
+
 func (s *LimiterSuite) TestRecordAllSuccess() {
 	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:20240229231111", int64(10)).Return(nil)
 	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:202402292311", int64(10)).Return(nil)
@@ -102,7 +108,7 @@ func (s *LimiterSuite) TestCheckDayLimitNotSet() {
 		"metric_test": {},
 	}
 	s.l = limiter.New(s.adapter, limits)
-	s.adapter.EXPECT().SumKeys(s.ctx, dayKeys).Return(int64(250), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, metricKeys("metric_test", dayKeys)).Return(int64(250), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationDay)
 	s.Error(err)
@@ -114,7 +120,7 @@ func (s *LimiterSuite) TestCheckHourLimitNotSet() {
 		"metric_test": {},
 	}
 	s.l = limiter.New(s.adapter, limits)
-	s.adapter.EXPECT().SumKeys(s.ctx, hourKeys).Return(int64(25), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, metricKeys("metric_test", hourKeys)).Return(int64(25), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationHour)
 	s.Error(err)
@@ -126,7 +132,7 @@ func (s *LimiterSuite) TestCheckMinuteLimitNotSet() {
 		"metric_test": {},
 	}
 	s.l = limiter.New(s.adapter, limits)
-	s.adapter.EXPECT().SumKeys(s.ctx, minuteKeys).Return(int64(5), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, metricKeys("metric_test", minuteKeys)).Return(int64(5), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationMinute)
 	s.Error(err)
@@ -138,7 +144,7 @@ func (s *LimiterSuite) TestCheckSecondLimitNotSet() {
 		"metric_test": {},
 	}
 	s.l = limiter.New(s.adapter, limits)
-	s.adapter.EXPECT().SumKeys(s.ctx, []string{"20240229231110"}).Return(int64(2), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:20240229231110"}).Return(int64(2), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationSecond)
 	s.Error(err)
@@ -146,33 +152,188 @@ func (s *LimiterSuite) TestCheckSecondLimitNotSet() {
 }
 
 func (s *LimiterSuite) TestCheckDayWithinLimit() {
-	s.adapter.EXPECT().SumKeys(s.ctx, dayKeys).Return(int64(250), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, metricKeys("metric_test", dayKeys)).Return(int64(250), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationDay)
 	s.NoError(err)
 }
 
 func (s *LimiterSuite) TestCheckHourWithinLimit() {
-	s.adapter.EXPECT().SumKeys(s.ctx, hourKeys).Return(int64(25), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, metricKeys("metric_test", hourKeys)).Return(int64(25), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationHour)
 	s.NoError(err)
 }
 
 func (s *LimiterSuite) TestCheckMinuteWithinLimit() {
-	s.adapter.EXPECT().SumKeys(s.ctx, minuteKeys).Return(int64(5), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, metricKeys("metric_test", minuteKeys)).Return(int64(5), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationMinute)
 	s.NoError(err)
 }
 
 func (s *LimiterSuite) TestCheckSecondWithinLimit() {
-	s.adapter.EXPECT().SumKeys(s.ctx, []string{"20240229231110"}).Return(int64(2), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:20240229231110"}).Return(int64(2), nil)
 
 	err := s.l.Check(s.ctx, "metric_test", limiter.DurationSecond)
 	s.NoError(err)
 }
 
+func (s *LimiterSuite) TestRecordForSubject() {
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:user1:20240229231111", int64(10)).Return(nil)
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:user1:202402292311", int64(10)).Return(nil)
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:user1:2024022923", int64(10)).Return(nil)
+
+	err := s.l.RecordFor(s.ctx, "metric_test", "user1", 10)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestCheckForSubjectExceeded() {
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(6), nil)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.Error(err)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestCheckForSubjectWithinLimit() {
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(5), nil)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+}
+
+var (
+	softLimits = map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 10}}
+	softOption = limiter.WithSoftLimits("metric_test", limiter.Limits{limiter.DurationSecond: 8})
+)
+
+func (s *LimiterSuite) TestEvaluateOK() {
+	s.newLimiter(s.adapter, softLimits, softOption)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(8), nil)
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.Result{Status: limiter.StatusOK, Usage: 8, Limit: 10, SoftLimit: 8}, res)
+	s.True(res.Allowed())
+}
+
+func (s *LimiterSuite) TestEvaluateWarning() {
+	s.newLimiter(s.adapter, softLimits, softOption)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(9), nil)
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.StatusWarning, res.Status)
+	s.True(res.Allowed())
+}
+
+func (s *LimiterSuite) TestEvaluateExceeded() {
+	s.newLimiter(s.adapter, softLimits, softOption)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(11), nil)
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.False(res.Allowed())
+}
+
+func (s *LimiterSuite) TestEvaluateWithoutSoftLimit() {
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(5), nil)
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.Result{Status: limiter.StatusOK, Usage: 5, Limit: 5}, res)
+}
+
+func (s *LimiterSuite) TestCheckForSoftLimitAllowed() {
+	s.newLimiter(s.adapter, softLimits, softOption)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(9), nil)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestShadowAllowsExceeded() {
+	var events []limiter.Event
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 5}},
+		limiter.WithShadow("metric_test"),
+		limiter.WithHook(func(_ context.Context, event limiter.Event) {
+			events = append(events, event)
+		}),
+	)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(6), nil).Times(2)
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.True(res.Shadow)
+	s.True(res.Allowed())
+
+	err = s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+
+	s.Require().Len(events, 1)
+	s.Equal(limiter.EventLimitExceeded, events[0].Kind)
+	s.True(events[0].Shadow)
+}
+
+func (s *LimiterSuite) TestShadowOtherMetricEnforced() {
+	limits := map[string]limiter.Limits{
+		"metric_test":   {limiter.DurationSecond: 5},
+		"metric_shadow": {limiter.DurationSecond: 5},
+	}
+	s.l = limiter.New(s.adapter, limits, limiter.WithShadow("metric_shadow"))
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(6), nil)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestFailOpenCheck() {
+	limits := map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 5}}
+	s.l = limiter.New(s.adapter, limits, limiter.WithFailPolicy("metric_test", limiter.FailOpen))
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:20240229231110"}).Return(int64(0), errors.New("mocked error"))
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.Result{Status: limiter.StatusOK, Fallback: true}, res)
+}
+
+func (s *LimiterSuite) TestFailOpenRecord() {
+	limits := map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 5}}
+	s.l = limiter.New(s.adapter, limits, limiter.WithFailPolicy("metric_test", limiter.FailOpen))
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:20240229231111", int64(10)).Return(errors.New("mocked error"))
+
+	err := s.l.Record(s.ctx, "metric_test", 10)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestFailClosedByDefault() {
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:20240229231110"}).Return(int64(0), mockedErr)
+
+	err := s.l.Check(s.ctx, "metric_test", limiter.DurationSecond)
+	s.ErrorIs(err, mockedErr)
+}
+
+func (s *LimiterSuite) TestCheckReadsRecordedUsage() {
+	s.l = limiter.New(memory.NewAdapter(), map[string]limiter.Limits{"metric_test": {limiter.DurationMinute: 3}})
+	s.Require().NoError(s.l.Record(s.ctx, "metric_test", 10))
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 12, 0, time.UTC))
+
+	s.ErrorIs(s.l.Check(s.ctx, "metric_test", limiter.DurationMinute), limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestLimits() {
+	limits, ok := s.l.Limits("metric_test")
+	s.True(ok)
+	s.Equal(int64(300), limits[limiter.DurationDay])
+
+	_, ok = s.l.Limits("unknown_metric")
+	s.False(ok)
+}
+
 func (s *LimiterSuite) TestGenerateKeysDay() {
 	keys := s.l.GenerateKeys(limiter.DurationDay)
 	s.Len(keys, 142)
@@ -197,6 +358,16 @@ func (s *LimiterSuite) TestGenerateKeysSecond() {
 	s.Equal("20240229231110", keys[0])
 }
 
+// metricKeys prefixes the keys with the metric, like the keys read by the checks.
+func metricKeys(metric string, keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = metric + ":" + key
+	}
+
+	return prefixed
+}
+
 var (
 	minuteKeys = []string{
 		"20240229231011",
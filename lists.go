@@ -0,0 +1,102 @@
+package limiter
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// SubjectList matches the subjects of an allowlist or a denylist.
+// It is immutable once built, so it can be swapped while checks are in flight.
+type SubjectList struct {
+	exact    map[string]bool
+	prefixes []string
+	networks []netip.Prefix
+}
+
+// NewSubjectList returns a new SubjectList matching the patterns.
+// A pattern is either a CIDR range matching the IP subjects, e.g. 10.0.0.0/8,
+// a prefix ending with *, e.g. svc-*, or an exact subject.
+func NewSubjectList(patterns ...string) (*SubjectList, error) {
+	list := &SubjectList{exact: make(map[string]bool)}
+	for _, pattern := range patterns {
+		switch {
+		case strings.Contains(pattern, "/"):
+			network, err := netip.ParsePrefix(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("limiter: invalid CIDR range %q: %w", pattern, err)
+			}
+			list.networks = append(list.networks, network.Masked())
+		case strings.HasSuffix(pattern, "*"):
+			list.prefixes = append(list.prefixes, strings.TrimSuffix(pattern, "*"))
+		default:
+			list.exact[pattern] = true
+		}
+	}
+
+	return list, nil
+}
+
+// Match reports whether the subject matches any pattern of the list.
+func (s *SubjectList) Match(subject string) bool {
+	if s == nil || subject == "" {
+		return false
+	}
+
+	if s.exact[subject] {
+		return true
+	}
+
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+
+	if len(s.networks) == 0 {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(subject)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range s.networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetAllowlist replaces the allowlist, nil clears it.
+// The allowlisted subjects bypass the limits, their usage is not recorded.
+func (l *Limiter) SetAllowlist(list *SubjectList) {
+	l.update(func(t *table) {
+		t.allowlist = list
+	})
+}
+
+// SetDenylist replaces the denylist, nil clears it.
+// The denylisted subjects are always denied, even if they are allowlisted too.
+func (l *Limiter) SetDenylist(list *SubjectList) {
+	l.update(func(t *table) {
+		t.denylist = list
+	})
+}
+
+// listed reports whether the subject is allowlisted or denylisted, the denylist taking precedence.
+func (t *table) listed(subject string) (allowed, denied bool) {
+	if t.denylist.Match(subject) {
+		return false, true
+	}
+
+	return t.allowlist.Match(subject), false
+}
+
+// errDenylisted is returned for the denylisted subjects.
+func errDenylisted() error {
+	return fmt.Errorf("%w: subject denylisted", ErrLimitExceeded)
+}
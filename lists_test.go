@@ -0,0 +1,126 @@
+package limiter_test
+
+import (
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+func (s *LimiterSuite) TestSubjectListMatch() {
+	list, err := limiter.NewSubjectList("monitoring", "svc-*", "10.0.0.0/8", "2001:db8::/32")
+	s.Require().NoError(err)
+
+	for subject, match := range map[string]bool{
+		"monitoring":       true,
+		"monitoring-2":     false,
+		"svc-billing":      true,
+		"svc":              false,
+		"10.1.2.3":         true,
+		"::ffff:10.1.2.3":  true,
+		"11.1.2.3":         false,
+		"2001:db8::1":      true,
+		"2001:db9::1":      false,
+		"not an ip/prefix": false,
+		"":                 false,
+	} {
+		s.Equal(match, list.Match(subject), subject)
+	}
+
+	_, err = limiter.NewSubjectList("10.0.0.0/33")
+	s.ErrorContains(err, `invalid CIDR range "10.0.0.0/33"`)
+}
+
+var listedLimits = map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 5}}
+
+// listOptions allows the svc- subjects but svc-bad, denied with the 203.0.113.0/24 range, and limits the concurrent exports.
+func (s *LimiterSuite) listOptions() []limiter.Option {
+	allow, err := limiter.NewSubjectList("svc-*")
+	s.Require().NoError(err)
+	deny, err := limiter.NewSubjectList("203.0.113.0/24", "svc-bad")
+	s.Require().NoError(err)
+
+	return []limiter.Option{
+		limiter.WithAllowlist(allow),
+		limiter.WithDenylist(deny),
+		limiter.WithConcurrency("exports", 1, time.Minute),
+	}
+}
+
+func (s *LimiterSuite) TestAllowlistBypassesAdapter() {
+	s.newLimiter(s.adapter, listedLimits, s.listOptions()...)
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "svc-billing", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(limiter.Result{Status: limiter.StatusOK, Allowlisted: true}, res)
+
+	s.NoError(s.l.RecordFor(s.ctx, "metric_test", "svc-billing", 100))
+	_, err = s.l.RecordAll(s.ctx, "svc-billing", limiter.Cost{Metric: "metric_test", Value: 100})
+	s.NoError(err)
+	s.NoError(s.l.WaitFor(s.ctx, "metric_test", "svc-billing", 1))
+	lease, err := s.l.Acquire(s.ctx, "exports", "svc-billing")
+	s.Require().NoError(err)
+	s.True(lease.Bypassed)
+	_, err = s.l.Renew(s.ctx, lease)
+	s.NoError(err)
+	s.NoError(s.l.Release(s.ctx, lease))
+
+	r, err := s.l.Reserve(s.ctx, "metric_test", "svc-billing", 1)
+	s.Require().NoError(err)
+	s.Empty(r.Receipt.Increments)
+}
+
+func (s *LimiterSuite) TestDenylistBlocksOutright() {
+	s.newLimiter(s.adapter, listedLimits, s.listOptions()...)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "203.0.113.7", limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "subject denylisted")
+
+	// the denylist takes precedence over the allowlist.
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "svc-bad", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.True(res.Denylisted)
+	s.False(res.Allowed())
+
+	_, err = s.l.RecordAll(s.ctx, "svc-bad", limiter.Cost{Metric: "metric_test", Value: 1})
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	_, err = s.l.Acquire(s.ctx, "exports", "svc-bad")
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestListsReload() {
+	s.newLimiter(s.adapter, listedLimits, s.listOptions()...)
+	s.l.SetDenylist(nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:203.0.113.7:20240229231110"}).Return(int64(1), nil)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "203.0.113.7", limiter.DurationSecond)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestListsHierarchy() {
+	allow, err := limiter.NewSubjectList("svc-*")
+	s.Require().NoError(err)
+	deny, err := limiter.NewSubjectList("203.0.113.0/24")
+	s.Require().NoError(err)
+	s.newLimiter(s.adapter, hierarchyLimits, hierarchyOption,
+		limiter.WithAllowlist(allow),
+		limiter.WithDenylist(deny))
+
+	// only the level of the unlisted subject is read and recorded.
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"project_calls:web:20240229231110"}).Return(int64(0), nil)
+	results, err := s.l.EvaluateHierarchy(s.ctx, "calls", []string{"svc-acme", "web", "203.0.113.7"}, limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.True(results[0].Allowlisted)
+	s.Equal(limiter.StatusOK, results[1].Status)
+	s.True(results[2].Denylisted)
+
+	err = s.l.CheckHierarchy(s.ctx, "calls", []string{"svc-acme", "svc-web", "203.0.113.7"}, limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "user_calls 203.0.113.7")
+
+	s.adapter.EXPECT().IncrBy(s.ctx, gomock.Any(), int64(1)).Times(3)
+	s.NoError(s.l.RecordHierarchy(s.ctx, "calls", []string{"svc-acme", "web", "203.0.113.7"}, 1))
+	s.NoError(s.l.RecordHierarchy(s.ctx, "calls", []string{"svc-acme", "svc-web", "203.0.113.7"}, 1))
+}
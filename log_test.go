@@ -0,0 +1,105 @@
+package limiter_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+var (
+	logLimits = map[string]limiter.Limits{"audit": {limiter.DurationSecond: 2, limiter.DurationMinute: 5}}
+	logOption = limiter.WithAlgorithm("audit", limiter.AlgorithmSlidingLog)
+)
+
+func (s *LimiterSuite) TestSlidingLogExactWindow() {
+	s.newLimiter(memory.NewAdapter(), logLimits, logOption)
+	start := limiter.Now()
+
+	s.Require().NoError(s.l.RecordFor(s.ctx, "audit", "acme", 3))
+	s.setNow(start.Add(30 * time.Second))
+	s.Require().NoError(s.l.RecordFor(s.ctx, "audit", "acme", 3))
+
+	// the current event is counted, unlike the buckets of the sliding window.
+	res, err := s.l.Evaluate(s.ctx, "audit", "acme", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.Equal(int64(3), res.Usage)
+
+	s.ErrorIs(s.l.CheckFor(s.ctx, "audit", "acme", limiter.DurationMinute), limiter.ErrLimitExceeded)
+
+	// the first event leaves the window exactly a minute after it was recorded.
+	s.setNow(start.Add(time.Minute - time.Microsecond))
+	res, err = s.l.Evaluate(s.ctx, "audit", "acme", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal(int64(6), res.Usage)
+
+	s.setNow(start.Add(time.Minute))
+	res, err = s.l.Evaluate(s.ctx, "audit", "acme", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal(int64(3), res.Usage)
+	s.Equal(limiter.StatusOK, res.Status)
+}
+
+func (s *LimiterSuite) TestSlidingLogEntries() {
+	s.newLimiter(memory.NewAdapter(), logLimits, logOption)
+	start := limiter.Now()
+
+	s.Require().NoError(s.l.RecordFor(s.ctx, "audit", "acme", 1))
+	s.setNow(start.Add(1500 * time.Millisecond))
+	s.Require().NoError(s.l.RecordFor(s.ctx, "audit", "acme", 2))
+	s.Require().NoError(s.l.RecordFor(s.ctx, "audit", "globex", 4))
+
+	entries, err := s.l.LogEntries(s.ctx, "audit", "acme", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Equal([]limiter.LogEntry{{At: start, Value: 1}, {At: start.Add(1500 * time.Millisecond), Value: 2}}, entries)
+
+	entries, err = s.l.LogEntries(s.ctx, "audit", "acme", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal([]limiter.LogEntry{{At: start.Add(1500 * time.Millisecond), Value: 2}}, entries)
+}
+
+func (s *LimiterSuite) TestSlidingLogFallback() {
+	s.newLimiter(s.adapter, logLimits, logOption)
+	now := limiter.Now()
+	entries := []limiter.LogEntry{{At: now, Value: 3}}
+	s.adapter.EXPECT().Get(s.ctx, "log:audit:acme", gomock.Any()).Return(limiter.ErrCacheMiss)
+	s.adapter.EXPECT().Set(s.ctx, "log:audit:acme", entries, time.Minute).Return(nil)
+
+	s.Require().NoError(s.l.RecordFor(s.ctx, "audit", "acme", 3))
+}
+
+func (s *LimiterSuite) TestSlidingLogErrors() {
+	s.newLimiter(s.adapter, logLimits, logOption, limiter.WithFailPolicy("audit", limiter.FailOpen))
+
+	s.adapter.EXPECT().Get(s.ctx, "log:audit:acme", gomock.Any()).Return(errors.New("mocked error"))
+	res, err := s.l.Evaluate(s.ctx, "audit", "acme", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.True(res.Fallback)
+
+	_, err = s.l.LogEntries(s.ctx, "unknown", "acme", limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrMetricNotFound)
+}
+
+func (s *LimiterSuite) TestSlidingLogUnsupportedOperations() {
+	s.newLimiter(memory.NewAdapter(), logLimits, logOption)
+
+	_, err := s.l.RecordAll(s.ctx, "acme", limiter.Cost{Metric: "audit", Value: 1})
+	s.ErrorContains(err, "sliding_log algorithm")
+
+	_, err = s.l.Reserve(s.ctx, "audit", "acme", 1)
+	s.ErrorContains(err, "sliding_log algorithm")
+
+	_, err = s.l.RecordReceipt(s.ctx, "audit", "acme", 1)
+	s.ErrorContains(err, "sliding_log algorithm")
+	entries, err := s.l.LogEntries(s.ctx, "audit", "acme", limiter.DurationMinute)
+	s.Require().NoError(err)
+	s.Empty(entries)
+
+	s.l.SetAlgorithm("audit", limiter.AlgorithmSlidingWindow)
+	_, err = s.l.LogEntries(s.ctx, "audit", "acme", limiter.DurationSecond)
+	s.ErrorContains(err, "sliding_window algorithm")
+}
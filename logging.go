@@ -0,0 +1,109 @@
+package limiter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// logSampler limits how many records of the same kind are logged per interval.
+// The first records of every interval are logged, then only every thereafter-th.
+type logSampler struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+
+	mu     sync.Mutex
+	counts map[string]*sampleCount
+}
+
+type sampleCount struct {
+	start time.Time
+	n     int
+}
+
+func newLogSampler(interval time.Duration, first, thereafter int) *logSampler {
+	return &logSampler{
+		interval:   interval,
+		first:      first,
+		thereafter: thereafter,
+		counts:     make(map[string]*sampleCount),
+	}
+}
+
+// allow reports whether the record identified by key should be logged.
+func (s *logSampler) allow(key string) bool {
+	now := Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, ok := s.counts[key]
+	if !ok || now.Sub(count.start) >= s.interval {
+		count = &sampleCount{start: now}
+		s.counts[key] = count
+	}
+	count.n++
+
+	if count.n <= s.first {
+		return true
+	}
+
+	return s.thereafter > 0 && (count.n-s.first)%s.thereafter == 0
+}
+
+// log writes the record when a logger is configured and the sampler allows it.
+func (l *Limiter) log(ctx context.Context, level slog.Level, msg, metric string, attrs ...slog.Attr) {
+	if l.logger == nil || !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	if l.sampler != nil && !l.sampler.allow(msg+"\x00"+metric) {
+		return
+	}
+
+	l.logger.LogAttrs(ctx, level, msg, append([]slog.Attr{slog.String("metric", metric)}, attrs...)...)
+}
+
+func (l *Limiter) logDenied(ctx context.Context, metric, subject string, duration Duration, res Result) {
+	l.log(ctx, slog.LevelInfo, "limiter: limit exceeded", metric,
+		slog.String("subject", subject),
+		slog.String("window", duration.String()),
+		slog.Int64("usage", res.Usage),
+		slog.Int64("limit", res.Limit),
+		slog.Bool("shadow", res.Shadow),
+	)
+}
+
+func (l *Limiter) logWarning(ctx context.Context, metric, subject string, duration Duration, res Result) {
+	l.log(ctx, slog.LevelWarn, "limiter: soft limit exceeded", metric,
+		slog.String("subject", subject),
+		slog.String("window", duration.String()),
+		slog.Int64("usage", res.Usage),
+		slog.Int64("limit", res.SoftLimit),
+		slog.Bool("shadow", res.Shadow),
+	)
+}
+
+func (l *Limiter) logFallback(ctx context.Context, operation, metric, subject string) {
+	l.log(ctx, slog.LevelWarn, "limiter: adapter unavailable, failing open", metric,
+		slog.String("subject", subject),
+		slog.String("operation", operation),
+	)
+}
+
+func (l *Limiter) logProviderError(ctx context.Context, metric, subject string, err error) {
+	l.log(ctx, slog.LevelError, "limiter: limit provider failed, using the metric limits", metric,
+		slog.String("subject", subject),
+		slog.Any("error", err),
+	)
+}
+
+func (l *Limiter) logAdapterError(ctx context.Context, operation, metric, subject string, err error) {
+	l.log(ctx, slog.LevelError, "limiter: adapter call failed", metric,
+		slog.String("subject", subject),
+		slog.String("operation", operation),
+		slog.Any("error", err),
+	)
+}
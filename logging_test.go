@@ -0,0 +1,100 @@
+package limiter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+func (s *LimiterSuite) newLogger() (*slog.Logger, *bytes.Buffer) {
+	buff := new(bytes.Buffer)
+	return slog.New(slog.NewJSONHandler(buff, nil)), buff
+}
+
+func (s *LimiterSuite) logRecords(buff *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buff.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var record map[string]interface{}
+		s.Require().NoError(json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func (s *LimiterSuite) TestLogDenied() {
+	logger, buff := s.newLogger()
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 5}}, limiter.WithLogger(logger))
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(6), nil)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+
+	records := s.logRecords(buff)
+	s.Require().Len(records, 1)
+	s.Equal("INFO", records[0]["level"])
+	s.Equal("limiter: limit exceeded", records[0]["msg"])
+	s.Equal("metric_test", records[0]["metric"])
+	s.Equal("user1", records[0]["subject"])
+	s.Equal("second", records[0]["window"])
+	s.InDelta(6, records[0]["usage"], 0)
+	s.InDelta(5, records[0]["limit"], 0)
+}
+
+func (s *LimiterSuite) TestLogAdapterError() {
+	logger, buff := s.newLogger()
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{"metric_test": {}}, limiter.WithLogger(logger))
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:20240229231111", int64(1)).Return(errors.New("mocked error"))
+
+	err := s.l.Record(s.ctx, "metric_test", 1)
+	s.Error(err)
+
+	records := s.logRecords(buff)
+	s.Require().Len(records, 1)
+	s.Equal("ERROR", records[0]["level"])
+	s.Equal("IncrBy", records[0]["operation"])
+	s.Equal("mocked error", records[0]["error"])
+}
+
+func (s *LimiterSuite) TestLogSampling() {
+	logger, buff := s.newLogger()
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 5}},
+		limiter.WithLogger(logger),
+		limiter.WithLogSampling(time.Second, 2, 3),
+	)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(6), nil).Times(11)
+
+	for i := 0; i < 10; i++ {
+		s.ErrorIs(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond), limiter.ErrLimitExceeded)
+	}
+	// the 1st, 2nd, 5th and 8th denials are logged.
+	s.Len(s.logRecords(buff), 4)
+
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 12, 0, time.UTC)
+	}
+	s.ErrorIs(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond), limiter.ErrLimitExceeded)
+	s.Len(s.logRecords(buff), 5)
+}
+
+func (s *LimiterSuite) TestLogDisabledLevel() {
+	buff := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buff, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{"metric_test": {limiter.DurationSecond: 5}}, limiter.WithLogger(logger))
+	s.adapter.EXPECT().SumKeys(context.Background(), gomock.Any()).Return(int64(6), nil)
+
+	s.ErrorIs(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond), limiter.ErrLimitExceeded)
+	s.Empty(buff.String())
+}
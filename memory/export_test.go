@@ -0,0 +1,9 @@
+package memory
+
+// Len returns the number of stored entries, including the expired ones not swept yet.
+func Len(a *Adapter) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.entries)
+}
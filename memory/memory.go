@@ -0,0 +1,438 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func (e entry) int() (int64, error) {
+	if len(e.value) == 0 {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(string(e.value), 10, 64)
+}
+
+// Adapter is an in-memory storage adapter.
+// It is meant for tests and single instance deployments, the usage is not shared between processes.
+type Adapter struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	// leases maps the lease IDs of every key to their expiry.
+	leases map[string]map[string]time.Time
+	// logs holds the sliding logs, sorted by time.
+	logs map[string]*eventLog
+	// swept is the last time the expired entries and logs were dropped.
+	swept time.Time
+}
+
+// sweepInterval is the minimum interval between two sweeps of the expired entries and logs.
+const sweepInterval = time.Minute
+
+// bucketWindow is the longest window reading a usage bucket, the day.
+const bucketWindow = 24 * time.Hour
+
+// bucketFormats are the timestamp suffixes of the usage bucket keys and the length of their buckets.
+var bucketFormats = []struct {
+	format string
+	length time.Duration
+}{
+	{"20060102150405", time.Second},
+	{"200601021504", time.Minute},
+	{"2006010215", time.Hour},
+	{"20060102", 24 * time.Hour},
+}
+
+var (
+	_ limiter.Adapter            = (*Adapter)(nil)
+	_ limiter.BatchAdapter       = (*Adapter)(nil)
+	_ limiter.AtomicAdapter      = (*Adapter)(nil)
+	_ limiter.LeaseAdapter       = (*Adapter)(nil)
+	_ limiter.LeakyBucketAdapter = (*Adapter)(nil)
+	_ limiter.LogAdapter         = (*Adapter)(nil)
+	_ limiter.ExpiringAdapter    = (*Adapter)(nil)
+)
+
+func NewAdapter() *Adapter {
+	return &Adapter{
+		entries: make(map[string]entry),
+		leases:  make(map[string]map[string]time.Time),
+		logs:    make(map[string]*eventLog),
+	}
+}
+
+func (a *Adapter) Get(_ context.Context, key string, value interface{}) error {
+	a.mu.Lock()
+	e, ok := a.lookup(key)
+	a.mu.Unlock()
+	if !ok {
+		return limiter.ErrCacheMiss
+	}
+
+	return json.Unmarshal(e.value, value)
+}
+
+func (a *Adapter) Set(_ context.Context, key string, value interface{}, expiration time.Duration) error {
+	buff, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	e := entry{value: buff}
+	if expiration > 0 {
+		e.expiresAt = limiter.Now().Add(expiration)
+	}
+
+	a.mu.Lock()
+	a.entries[key] = e
+	a.mu.Unlock()
+
+	return nil
+}
+
+func (a *Adapter) IncrBy(_ context.Context, key string, value int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.incrBy(key, value)
+}
+
+// IncrByExpire increments the key by value and returns its new value, the key expiring after expiration from its creation.
+func (a *Adapter) IncrByExpire(_ context.Context, key string, value int64, expiration time.Duration) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := limiter.Now()
+	a.sweep(now)
+
+	e, ok := a.lookup(key)
+	if !ok {
+		e.expiresAt = now.Add(expiration)
+	}
+	current, err := e.int()
+	if err != nil {
+		return 0, err
+	}
+
+	e.value = strconv.AppendInt(nil, current+value, 10)
+	a.entries[key] = e
+
+	return current + value, nil
+}
+
+func (a *Adapter) SumKeys(_ context.Context, keys []string) (int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.sumKeys(keys), nil
+}
+
+func (a *Adapter) IncrByKeys(ctx context.Context, keys []string, value int64) error {
+	for _, key := range keys {
+		if err := a.IncrBy(ctx, key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *Adapter) SumKeyGroups(ctx context.Context, groups [][]string) ([]int64, error) {
+	sums := make([]int64, len(groups))
+	for i, keys := range groups {
+		sum, err := a.SumKeys(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		sums[i] = sum
+	}
+
+	return sums, nil
+}
+
+// IncrIf applies the increments only if every condition holds, atomically under the adapter lock.
+func (a *Adapter) IncrIf(_ context.Context, conditions []limiter.Condition, increments []limiter.Increment) (int, int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, condition := range conditions {
+		if sum := a.sumKeys(condition.Keys); sum+condition.Cost > condition.Limit {
+			return i, sum, nil
+		}
+	}
+
+	for _, increment := range increments {
+		if err := a.incrBy(increment.Key, increment.Value); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return -1, 0, nil
+}
+
+// AcquireLease adds the lease to the key if it holds less than limit unexpired leases.
+func (a *Adapter) AcquireLease(_ context.Context, key, id string, limit int64, now time.Time, ttl time.Duration) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	held := a.heldLeases(key, now)
+	if _, ok := held[id]; !ok && int64(len(held)) >= limit {
+		return false, nil
+	}
+
+	if held == nil {
+		held = make(map[string]time.Time)
+		a.leases[key] = held
+	}
+	held[id] = now.Add(ttl)
+
+	return true, nil
+}
+
+// RenewLease extends an unexpired lease.
+func (a *Adapter) RenewLease(_ context.Context, key, id string, now time.Time, ttl time.Duration) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	held := a.heldLeases(key, now)
+	if _, ok := held[id]; !ok {
+		return false, nil
+	}
+	held[id] = now.Add(ttl)
+
+	return true, nil
+}
+
+// ReleaseLease removes the lease from the key.
+func (a *Adapter) ReleaseLease(_ context.Context, key, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.leases[key], id)
+	if len(a.leases[key]) == 0 {
+		delete(a.leases, key)
+	}
+
+	return nil
+}
+
+// Schedule books the next slot of the leaky bucket at key.
+// The time the bucket is drained is stored as an entry expiring at that time.
+func (a *Adapter) Schedule(_ context.Context, key string, now time.Time, increment, maxDelay time.Duration) (time.Time, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	at := now
+	if e, ok := a.lookup(key); ok {
+		drained, err := e.int()
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if d := time.Unix(0, drained).In(now.Location()); d.After(at) {
+			at = d
+		}
+	}
+	if at.Sub(now) > maxDelay {
+		return at, false, nil
+	}
+
+	next := at.Add(increment)
+	a.entries[key] = entry{value: strconv.AppendInt(nil, next.UnixNano(), 10), expiresAt: next}
+
+	return at, true, nil
+}
+
+// eventLog is a sliding log and the retention of its entries.
+type eventLog struct {
+	entries   []limiter.LogEntry
+	retention time.Duration
+}
+
+// evict drops the entries older than the retention at now.
+func (l *eventLog) evict(now time.Time) {
+	i := sort.Search(len(l.entries), func(i int) bool {
+		return l.entries[i].At.After(now.Add(-l.retention))
+	})
+	l.entries = l.entries[i:]
+}
+
+// AppendLog adds the entry to the sliding log at key, evicting the entries older than retention.
+func (a *Adapter) AppendLog(_ context.Context, key string, entry limiter.LogEntry, retention time.Duration) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.sweep(limiter.Now())
+	log, ok := a.logs[key]
+	if !ok {
+		log = &eventLog{}
+		a.logs[key] = log
+	}
+	log.retention = retention
+
+	i := sort.Search(len(log.entries), func(i int) bool {
+		return log.entries[i].At.After(entry.At)
+	})
+	log.entries = append(log.entries, limiter.LogEntry{})
+	copy(log.entries[i+1:], log.entries[i:])
+	log.entries[i] = entry
+	log.evict(entry.At)
+
+	return nil
+}
+
+// ReadLog returns the entries of the sliding log at key logged after since and up to until, oldest first.
+func (a *Adapter) ReadLog(_ context.Context, key string, since, until time.Time) ([]limiter.LogEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	log, ok := a.logs[key]
+	if !ok {
+		return nil, nil
+	}
+
+	log.evict(limiter.Now())
+	if len(log.entries) == 0 {
+		delete(a.logs, key)
+		return nil, nil
+	}
+
+	return limiter.LogRange(log.entries, since, until), nil
+}
+
+// heldLeases returns the leases of the key, dropping the expired ones.
+// It returns nil if the key holds no lease.
+// The caller must hold the lock.
+func (a *Adapter) heldLeases(key string, now time.Time) map[string]time.Time {
+	held := a.leases[key]
+	for id, expiresAt := range held {
+		if !now.Before(expiresAt) {
+			delete(held, id)
+		}
+	}
+
+	if len(held) == 0 {
+		delete(a.leases, key)
+		return nil
+	}
+
+	return held
+}
+
+// incrBy increments the key by value.
+// The caller must hold the lock.
+func (a *Adapter) incrBy(key string, value int64) error {
+	now := limiter.Now()
+	a.sweep(now)
+
+	e, ok := a.lookup(key)
+	if !ok {
+		e.expiresAt = bucketExpiry(key, now)
+		// a refund of a bucket no longer read by any window would never expire.
+		if !e.expiresAt.IsZero() && !now.Before(e.expiresAt) {
+			return nil
+		}
+	}
+	current, err := e.int()
+	if err != nil {
+		return err
+	}
+
+	e.value = strconv.AppendInt(nil, current+value, 10)
+	a.entries[key] = e
+
+	return nil
+}
+
+// sumKeys returns the sum of the keys holding an integer.
+// The caller must hold the lock.
+func (a *Adapter) sumKeys(keys []string) int64 {
+	var sum int64
+	for _, key := range keys {
+		e, ok := a.lookup(key)
+		if !ok {
+			continue
+		}
+
+		val, err := e.int()
+		if err != nil {
+			continue
+		}
+		sum += val
+	}
+
+	return sum
+}
+
+// sweep drops the expired entries and logs, at most once per sweepInterval,
+// so the buckets of the subjects never checked again do not accumulate.
+// The caller must hold the lock.
+func (a *Adapter) sweep(now time.Time) {
+	if now.Sub(a.swept) < sweepInterval {
+		return
+	}
+	a.swept = now
+
+	for key, e := range a.entries {
+		if e.expired(now) {
+			delete(a.entries, key)
+		}
+	}
+	for key, log := range a.logs {
+		if log.evict(now); len(log.entries) == 0 {
+			delete(a.logs, key)
+		}
+	}
+}
+
+// bucketExpiry returns the time the usage bucket stored at key is no longer read by any window,
+// once the longest window has passed its end.
+// It returns the zero time, i.e. no expiry, for the keys not ending with the timestamp of a bucket started by now.
+func bucketExpiry(key string, now time.Time) time.Time {
+	suffix := key[strings.LastIndex(key, ":")+1:]
+	for _, b := range bucketFormats {
+		if len(suffix) != len(b.format) {
+			continue
+		}
+
+		start, err := time.ParseInLocation(b.format, suffix, now.Location())
+		if err != nil || now.Before(start) {
+			return time.Time{}
+		}
+
+		return start.Add(b.length).Add(bucketWindow)
+	}
+
+	return time.Time{}
+}
+
+// lookup returns the entry of the key, dropping it if it has expired.
+// The caller must hold the lock.
+func (a *Adapter) lookup(key string) (entry, bool) {
+	e, ok := a.entries[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	if e.expired(limiter.Now()) {
+		delete(a.entries, key)
+		return entry{}, false
+	}
+
+	return e, true
+}
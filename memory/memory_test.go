@@ -0,0 +1,287 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+type MemorySuite struct {
+	suite.Suite
+
+	ctx     context.Context
+	now     time.Time
+	adapter *memory.Adapter
+}
+
+func TestMemory(t *testing.T) {
+	suite.Run(t, new(MemorySuite))
+}
+
+func (s *MemorySuite) SetupTest() {
+	s.ctx = context.Background()
+	s.adapter = memory.NewAdapter()
+
+	s.now = time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	limiter.Now = func() time.Time {
+		return s.now
+	}
+}
+
+// ==================== Get/Set Cases ====================
+
+func (s *MemorySuite) TestGetKeyFound() {
+	s.Require().NoError(s.adapter.Set(s.ctx, "mykey", 16, time.Hour))
+
+	var value int64
+	err := s.adapter.Get(s.ctx, "mykey", &value)
+
+	s.Require().NoError(err)
+	s.Equal(int64(16), value)
+}
+
+func (s *MemorySuite) TestGetKeyNotFound() {
+	var value int64
+	err := s.adapter.Get(s.ctx, "mykey", &value)
+
+	s.Require().Error(err)
+	s.ErrorIs(err, limiter.ErrCacheMiss)
+}
+
+func (s *MemorySuite) TestGetKeyExpired() {
+	s.Require().NoError(s.adapter.Set(s.ctx, "mykey", 16, time.Hour))
+	s.now = s.now.Add(time.Hour)
+
+	var value int64
+	err := s.adapter.Get(s.ctx, "mykey", &value)
+
+	s.Require().Error(err)
+	s.ErrorIs(err, limiter.ErrCacheMiss)
+}
+
+func (s *MemorySuite) TestSetStruct() {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	s.Require().NoError(s.adapter.Set(s.ctx, "mykey", payload{Name: "limiter"}, 0))
+
+	var value payload
+	err := s.adapter.Get(s.ctx, "mykey", &value)
+
+	s.Require().NoError(err)
+	s.Equal("limiter", value.Name)
+}
+
+// ==================== IncrBy/SumKeys Cases ====================
+
+func (s *MemorySuite) TestIncrBy() {
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "mykey", 16))
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "mykey", 4))
+
+	var value int64
+	err := s.adapter.Get(s.ctx, "mykey", &value)
+
+	s.Require().NoError(err)
+	s.Equal(int64(20), value)
+}
+
+func (s *MemorySuite) TestIncrByNotInteger() {
+	s.Require().NoError(s.adapter.Set(s.ctx, "mykey", "value", 0))
+
+	err := s.adapter.IncrBy(s.ctx, "mykey", 16)
+
+	s.Require().Error(err)
+}
+
+func (s *MemorySuite) TestSumKeys() {
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "key1", 1))
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "key2", 2))
+	s.Require().NoError(s.adapter.Set(s.ctx, "key3", "value", 0))
+
+	sum, err := s.adapter.SumKeys(s.ctx, []string{"key1", "key2", "key3", "key4"})
+
+	s.Require().NoError(err)
+	s.Equal(int64(3), sum)
+}
+
+// ==================== Batch Cases ====================
+
+func (s *MemorySuite) TestIncrByKeysAndSumKeyGroups() {
+	s.Require().NoError(s.adapter.IncrByKeys(s.ctx, []string{"key1", "key2", "key3"}, 2))
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "key3", 1))
+
+	sums, err := s.adapter.SumKeyGroups(s.ctx, [][]string{{"key1", "key2"}, {"key3"}, {"key4"}})
+
+	s.Require().NoError(err)
+	s.Equal([]int64{4, 3, 0}, sums)
+}
+
+// ==================== Atomic Cases ====================
+
+func (s *MemorySuite) TestIncrIf() {
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "a1", 6))
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "b1", 4))
+	conditions := []limiter.Condition{
+		{Keys: []string{"a1", "a2"}, Cost: 3, Limit: 20},
+		{Keys: []string{"b1", "b2"}, Cost: 1, Limit: 5},
+	}
+	increments := []limiter.Increment{{Key: "a2", Value: 3}, {Key: "b2", Value: 1}}
+
+	failed, _, err := s.adapter.IncrIf(s.ctx, conditions, increments)
+	s.Require().NoError(err)
+	s.Equal(-1, failed)
+
+	// b is now at its limit, a must not be incremented either.
+	failed, sum, err := s.adapter.IncrIf(s.ctx, conditions, increments)
+	s.Require().NoError(err)
+	s.Equal(1, failed)
+	s.Equal(int64(5), sum)
+
+	sums, err := s.adapter.SumKeyGroups(s.ctx, [][]string{{"a1", "a2"}, {"b1", "b2"}})
+	s.Require().NoError(err)
+	s.Equal([]int64{9, 5}, sums)
+}
+
+// ==================== Lease Cases ====================
+
+func (s *MemorySuite) TestLeases() {
+	ok, err := s.adapter.AcquireLease(s.ctx, "lease", "a", 1, s.now, time.Minute)
+	s.Require().NoError(err)
+	s.True(ok)
+
+	ok, err = s.adapter.AcquireLease(s.ctx, "lease", "b", 1, s.now, time.Minute)
+	s.Require().NoError(err)
+	s.False(ok)
+
+	ok, err = s.adapter.RenewLease(s.ctx, "lease", "a", s.now.Add(30*time.Second), time.Minute)
+	s.Require().NoError(err)
+	s.True(ok)
+
+	ok, err = s.adapter.AcquireLease(s.ctx, "lease", "b", 1, s.now.Add(time.Minute), time.Minute)
+	s.Require().NoError(err)
+	s.False(ok)
+
+	ok, err = s.adapter.RenewLease(s.ctx, "lease", "a", s.now.Add(90*time.Second), time.Minute)
+	s.Require().NoError(err)
+	s.False(ok)
+
+	s.Require().NoError(s.adapter.ReleaseLease(s.ctx, "lease", "a"))
+	ok, err = s.adapter.AcquireLease(s.ctx, "lease", "b", 1, s.now, time.Minute)
+	s.Require().NoError(err)
+	s.True(ok)
+}
+
+// ==================== Leaky Bucket Cases ====================
+
+func (s *MemorySuite) TestSchedule() {
+	at, ok, err := s.adapter.Schedule(s.ctx, "bucket", s.now, time.Second, time.Second)
+	s.Require().NoError(err)
+	s.True(ok)
+	s.Equal(s.now, at)
+
+	at, ok, err = s.adapter.Schedule(s.ctx, "bucket", s.now, time.Second, time.Second)
+	s.Require().NoError(err)
+	s.True(ok)
+	s.Equal(s.now.Add(time.Second), at)
+
+	_, ok, err = s.adapter.Schedule(s.ctx, "bucket", s.now, time.Second, time.Second)
+	s.Require().NoError(err)
+	s.False(ok)
+
+	// the bucket entry expires once drained.
+	s.now = s.now.Add(2 * time.Second)
+	at, ok, err = s.adapter.Schedule(s.ctx, "bucket", s.now, time.Second, 0)
+	s.Require().NoError(err)
+	s.True(ok)
+	s.Equal(s.now, at)
+}
+
+// ==================== Sliding Log Cases ====================
+
+func (s *MemorySuite) TestLogs() {
+	s.Require().NoError(s.adapter.AppendLog(s.ctx, "log", limiter.LogEntry{At: s.now, Value: 2}, time.Minute))
+	s.Require().NoError(s.adapter.AppendLog(s.ctx, "log", limiter.LogEntry{At: s.now.Add(-time.Second), Value: 1}, time.Minute))
+	s.Require().NoError(s.adapter.AppendLog(s.ctx, "log", limiter.LogEntry{At: s.now.Add(30 * time.Second), Value: 3}, time.Minute))
+
+	entries, err := s.adapter.ReadLog(s.ctx, "log", s.now.Add(-time.Second), s.now.Add(30*time.Second))
+	s.Require().NoError(err)
+	s.Equal([]limiter.LogEntry{{At: s.now, Value: 2}, {At: s.now.Add(30 * time.Second), Value: 3}}, entries)
+
+	// the entries leave the log exactly a retention after they were logged.
+	s.now = s.now.Add(time.Minute)
+	entries, err = s.adapter.ReadLog(s.ctx, "log", s.now.Add(-time.Hour), s.now)
+	s.Require().NoError(err)
+	s.Equal([]limiter.LogEntry{{At: s.now.Add(-30 * time.Second), Value: 3}}, entries)
+
+	s.now = s.now.Add(time.Minute)
+	entries, err = s.adapter.ReadLog(s.ctx, "log", s.now.Add(-time.Hour), s.now)
+	s.Require().NoError(err)
+	s.Empty(entries)
+}
+
+// ==================== Expiry Cases ====================
+
+func (s *MemorySuite) TestIncrByExpire() {
+	current, err := s.adapter.IncrByExpire(s.ctx, "counter", 2, time.Minute)
+	s.Require().NoError(err)
+	s.Equal(int64(2), current)
+
+	// the expiry is set by the increment creating the key only.
+	s.now = s.now.Add(30 * time.Second)
+	current, err = s.adapter.IncrByExpire(s.ctx, "counter", 1, time.Minute)
+	s.Require().NoError(err)
+	s.Equal(int64(3), current)
+
+	s.now = s.now.Add(30 * time.Second)
+	current, err = s.adapter.IncrByExpire(s.ctx, "counter", 1, time.Minute)
+	s.Require().NoError(err)
+	s.Equal(int64(1), current)
+}
+
+func (s *MemorySuite) TestIncrByExpiresBuckets() {
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "m:s:20240229231111", 1))
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "m:s:2024022923", 1))
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "penalty:m:s:0:1709248271", 1))
+
+	// the second bucket is read by the day window until a day after it ends.
+	s.now = s.now.Add(24 * time.Hour)
+	sums, err := s.adapter.SumKeyGroups(s.ctx, [][]string{{"m:s:20240229231111"}, {"m:s:2024022923"}})
+	s.Require().NoError(err)
+	s.Equal([]int64{1, 1}, sums)
+
+	s.now = s.now.Add(time.Hour)
+	sums, err = s.adapter.SumKeyGroups(s.ctx, [][]string{{"m:s:20240229231111"}, {"m:s:2024022923"}, {"penalty:m:s:0:1709248271"}})
+	s.Require().NoError(err)
+	s.Equal([]int64{0, 0, 1}, sums)
+}
+
+func (s *MemorySuite) TestIncrBySweepsExpiredBuckets() {
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "m:s:20240229231111", 1))
+
+	// the bucket is dropped by the next write, even if never read again.
+	s.now = s.now.Add(25 * time.Hour)
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "m:t:20240301001111", 1))
+	s.Equal(1, memory.Len(s.adapter))
+}
+
+func (s *MemorySuite) TestIncrBySkipsRefundsOfExpiredBuckets() {
+	s.now = s.now.Add(25 * time.Hour)
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "m:s:20240229231111", -1))
+	s.Require().NoError(s.adapter.IncrBy(s.ctx, "m:s:20240302001110", -1))
+
+	sums, err := s.adapter.SumKeyGroups(s.ctx, [][]string{{"m:s:20240229231111"}, {"m:s:20240302001110"}})
+	s.Require().NoError(err)
+	s.Equal([]int64{0, -1}, sums)
+	s.Equal(1, memory.Len(s.adapter))
+
+	// the refunded bucket still read by the day window expires with it.
+	s.now = s.now.Add(24 * time.Hour)
+	sum, err := s.adapter.SumKeys(s.ctx, []string{"m:s:20240302001110"})
+	s.Require().NoError(err)
+	s.Zero(sum)
+}
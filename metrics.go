@@ -0,0 +1,158 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Adapter operation names reported to Metrics.
+const (
+	OperationGet          = "Get"
+	OperationSet          = "Set"
+	OperationIncrBy       = "IncrBy"
+	OperationSumKeys      = "SumKeys"
+	OperationIncrByKeys   = "IncrByKeys"
+	OperationSumKeyGroups = "SumKeyGroups"
+	OperationIncrIf       = "IncrIf"
+	OperationAcquireLease = "AcquireLease"
+	OperationRenewLease   = "RenewLease"
+	OperationReleaseLease = "ReleaseLease"
+	OperationSchedule     = "Schedule"
+	OperationAppendLog    = "AppendLog"
+	OperationReadLog      = "ReadLog"
+	OperationIncrByExpire = "IncrByExpire"
+)
+
+// Metrics receives the limiter decisions and adapter calls.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveDecision is called once a check has been evaluated against its limit.
+	// allowed is the decision the limits lead to, shadow reports it was not enforced.
+	ObserveDecision(metric string, duration Duration, allowed, shadow bool)
+	// ObserveAdapterCall is called after every adapter call made by the limiter.
+	ObserveAdapterCall(operation string, elapsed time.Duration, err error)
+}
+
+// observedAdapter reports the latency and errors of the wrapped adapter calls.
+type observedAdapter struct {
+	Adapter
+	metrics Metrics
+}
+
+func (a *observedAdapter) Get(ctx context.Context, key string, value interface{}) error {
+	start := time.Now()
+	err := a.Adapter.Get(ctx, key, value)
+	if errors.Is(err, ErrCacheMiss) {
+		// a missing key is an expected outcome rather than a failure.
+		a.metrics.ObserveAdapterCall(OperationGet, time.Since(start), nil)
+		return err
+	}
+	a.metrics.ObserveAdapterCall(OperationGet, time.Since(start), err)
+
+	return err
+}
+
+func (a *observedAdapter) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	start := time.Now()
+	err := a.Adapter.Set(ctx, key, value, expiration)
+	a.metrics.ObserveAdapterCall(OperationSet, time.Since(start), err)
+
+	return err
+}
+
+func (a *observedAdapter) IncrBy(ctx context.Context, key string, value int64) error {
+	start := time.Now()
+	err := a.Adapter.IncrBy(ctx, key, value)
+	a.metrics.ObserveAdapterCall(OperationIncrBy, time.Since(start), err)
+
+	return err
+}
+
+func (a *observedAdapter) SumKeys(ctx context.Context, keys []string) (int64, error) {
+	start := time.Now()
+	sum, err := a.Adapter.SumKeys(ctx, keys)
+	a.metrics.ObserveAdapterCall(OperationSumKeys, time.Since(start), err)
+
+	return sum, err
+}
+
+func (a *observedAdapter) IncrByKeys(ctx context.Context, keys []string, value int64) error {
+	start := time.Now()
+	err := IncrByKeys(ctx, a.Adapter, keys, value)
+	a.metrics.ObserveAdapterCall(OperationIncrByKeys, time.Since(start), err)
+
+	return err
+}
+
+func (a *observedAdapter) SumKeyGroups(ctx context.Context, groups [][]string) ([]int64, error) {
+	start := time.Now()
+	sums, err := SumKeyGroups(ctx, a.Adapter, groups)
+	a.metrics.ObserveAdapterCall(OperationSumKeyGroups, time.Since(start), err)
+
+	return sums, err
+}
+
+func (a *observedAdapter) IncrIf(ctx context.Context, conditions []Condition, increments []Increment) (int, int64, error) {
+	start := time.Now()
+	failed, sum, err := IncrIf(ctx, a.Adapter, conditions, increments)
+	a.metrics.ObserveAdapterCall(OperationIncrIf, time.Since(start), err)
+
+	return failed, sum, err
+}
+
+func (a *observedAdapter) AcquireLease(ctx context.Context, key, id string, limit int64, now time.Time, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	ok, err := AcquireLease(ctx, a.Adapter, key, id, limit, now, ttl)
+	a.metrics.ObserveAdapterCall(OperationAcquireLease, time.Since(start), err)
+
+	return ok, err
+}
+
+func (a *observedAdapter) RenewLease(ctx context.Context, key, id string, now time.Time, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	ok, err := RenewLease(ctx, a.Adapter, key, id, now, ttl)
+	a.metrics.ObserveAdapterCall(OperationRenewLease, time.Since(start), err)
+
+	return ok, err
+}
+
+func (a *observedAdapter) ReleaseLease(ctx context.Context, key, id string) error {
+	start := time.Now()
+	err := ReleaseLease(ctx, a.Adapter, key, id)
+	a.metrics.ObserveAdapterCall(OperationReleaseLease, time.Since(start), err)
+
+	return err
+}
+
+func (a *observedAdapter) Schedule(ctx context.Context, key string, now time.Time, increment, maxDelay time.Duration) (time.Time, bool, error) {
+	start := time.Now()
+	at, ok, err := Schedule(ctx, a.Adapter, key, now, increment, maxDelay)
+	a.metrics.ObserveAdapterCall(OperationSchedule, time.Since(start), err)
+
+	return at, ok, err
+}
+
+func (a *observedAdapter) AppendLog(ctx context.Context, key string, entry LogEntry, retention time.Duration) error {
+	start := time.Now()
+	err := AppendLog(ctx, a.Adapter, key, entry, retention)
+	a.metrics.ObserveAdapterCall(OperationAppendLog, time.Since(start), err)
+
+	return err
+}
+
+func (a *observedAdapter) ReadLog(ctx context.Context, key string, since, until time.Time) ([]LogEntry, error) {
+	start := time.Now()
+	entries, err := ReadLog(ctx, a.Adapter, key, since, until)
+	a.metrics.ObserveAdapterCall(OperationReadLog, time.Since(start), err)
+
+	return entries, err
+}
+
+func (a *observedAdapter) IncrByExpire(ctx context.Context, key string, value int64, expiration time.Duration) (int64, error) {
+	start := time.Now()
+	current, err := IncrByExpire(ctx, a.Adapter, key, value, expiration)
+	a.metrics.ObserveAdapterCall(OperationIncrByExpire, time.Since(start), err)
+
+	return current, err
+}
@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	limiter "github.com/hendrywiranto/limiter"
 )
 
 // MockAdapter is a mock of Adapter interface.
@@ -91,3 +92,290 @@ func (mr *MockAdapterMockRecorder) SumKeys(ctx, keys interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumKeys", reflect.TypeOf((*MockAdapter)(nil).SumKeys), ctx, keys)
 }
+
+// MockBatchAdapter is a mock of BatchAdapter interface.
+type MockBatchAdapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchAdapterMockRecorder
+}
+
+// MockBatchAdapterMockRecorder is the mock recorder for MockBatchAdapter.
+type MockBatchAdapterMockRecorder struct {
+	mock *MockBatchAdapter
+}
+
+// NewMockBatchAdapter creates a new mock instance.
+func NewMockBatchAdapter(ctrl *gomock.Controller) *MockBatchAdapter {
+	mock := &MockBatchAdapter{ctrl: ctrl}
+	mock.recorder = &MockBatchAdapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchAdapter) EXPECT() *MockBatchAdapterMockRecorder {
+	return m.recorder
+}
+
+// IncrByKeys mocks base method.
+func (m *MockBatchAdapter) IncrByKeys(ctx context.Context, keys []string, value int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrByKeys", ctx, keys, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrByKeys indicates an expected call of IncrByKeys.
+func (mr *MockBatchAdapterMockRecorder) IncrByKeys(ctx, keys, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrByKeys", reflect.TypeOf((*MockBatchAdapter)(nil).IncrByKeys), ctx, keys, value)
+}
+
+// SumKeyGroups mocks base method.
+func (m *MockBatchAdapter) SumKeyGroups(ctx context.Context, groups [][]string) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumKeyGroups", ctx, groups)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumKeyGroups indicates an expected call of SumKeyGroups.
+func (mr *MockBatchAdapterMockRecorder) SumKeyGroups(ctx, groups interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumKeyGroups", reflect.TypeOf((*MockBatchAdapter)(nil).SumKeyGroups), ctx, groups)
+}
+
+// MockExpiringAdapter is a mock of ExpiringAdapter interface.
+type MockExpiringAdapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockExpiringAdapterMockRecorder
+}
+
+// MockExpiringAdapterMockRecorder is the mock recorder for MockExpiringAdapter.
+type MockExpiringAdapterMockRecorder struct {
+	mock *MockExpiringAdapter
+}
+
+// NewMockExpiringAdapter creates a new mock instance.
+func NewMockExpiringAdapter(ctrl *gomock.Controller) *MockExpiringAdapter {
+	mock := &MockExpiringAdapter{ctrl: ctrl}
+	mock.recorder = &MockExpiringAdapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExpiringAdapter) EXPECT() *MockExpiringAdapterMockRecorder {
+	return m.recorder
+}
+
+// IncrByExpire mocks base method.
+func (m *MockExpiringAdapter) IncrByExpire(ctx context.Context, key string, value int64, expiration time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrByExpire", ctx, key, value, expiration)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrByExpire indicates an expected call of IncrByExpire.
+func (mr *MockExpiringAdapterMockRecorder) IncrByExpire(ctx, key, value, expiration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrByExpire", reflect.TypeOf((*MockExpiringAdapter)(nil).IncrByExpire), ctx, key, value, expiration)
+}
+
+// MockAtomicAdapter is a mock of AtomicAdapter interface.
+type MockAtomicAdapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockAtomicAdapterMockRecorder
+}
+
+// MockAtomicAdapterMockRecorder is the mock recorder for MockAtomicAdapter.
+type MockAtomicAdapterMockRecorder struct {
+	mock *MockAtomicAdapter
+}
+
+// NewMockAtomicAdapter creates a new mock instance.
+func NewMockAtomicAdapter(ctrl *gomock.Controller) *MockAtomicAdapter {
+	mock := &MockAtomicAdapter{ctrl: ctrl}
+	mock.recorder = &MockAtomicAdapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAtomicAdapter) EXPECT() *MockAtomicAdapterMockRecorder {
+	return m.recorder
+}
+
+// IncrIf mocks base method.
+func (m *MockAtomicAdapter) IncrIf(ctx context.Context, conditions []limiter.Condition, increments []limiter.Increment) (int, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrIf", ctx, conditions, increments)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IncrIf indicates an expected call of IncrIf.
+func (mr *MockAtomicAdapterMockRecorder) IncrIf(ctx, conditions, increments interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrIf", reflect.TypeOf((*MockAtomicAdapter)(nil).IncrIf), ctx, conditions, increments)
+}
+
+// MockLeaseAdapter is a mock of LeaseAdapter interface.
+type MockLeaseAdapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaseAdapterMockRecorder
+}
+
+// MockLeaseAdapterMockRecorder is the mock recorder for MockLeaseAdapter.
+type MockLeaseAdapterMockRecorder struct {
+	mock *MockLeaseAdapter
+}
+
+// NewMockLeaseAdapter creates a new mock instance.
+func NewMockLeaseAdapter(ctrl *gomock.Controller) *MockLeaseAdapter {
+	mock := &MockLeaseAdapter{ctrl: ctrl}
+	mock.recorder = &MockLeaseAdapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaseAdapter) EXPECT() *MockLeaseAdapterMockRecorder {
+	return m.recorder
+}
+
+// AcquireLease mocks base method.
+func (m *MockLeaseAdapter) AcquireLease(ctx context.Context, key, id string, limit int64, now time.Time, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLease", ctx, key, id, limit, now, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireLease indicates an expected call of AcquireLease.
+func (mr *MockLeaseAdapterMockRecorder) AcquireLease(ctx, key, id, limit, now, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLease", reflect.TypeOf((*MockLeaseAdapter)(nil).AcquireLease), ctx, key, id, limit, now, ttl)
+}
+
+// ReleaseLease mocks base method.
+func (m *MockLeaseAdapter) ReleaseLease(ctx context.Context, key, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLease", ctx, key, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLease indicates an expected call of ReleaseLease.
+func (mr *MockLeaseAdapterMockRecorder) ReleaseLease(ctx, key, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLease", reflect.TypeOf((*MockLeaseAdapter)(nil).ReleaseLease), ctx, key, id)
+}
+
+// RenewLease mocks base method.
+func (m *MockLeaseAdapter) RenewLease(ctx context.Context, key, id string, now time.Time, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewLease", ctx, key, id, now, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewLease indicates an expected call of RenewLease.
+func (mr *MockLeaseAdapterMockRecorder) RenewLease(ctx, key, id, now, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewLease", reflect.TypeOf((*MockLeaseAdapter)(nil).RenewLease), ctx, key, id, now, ttl)
+}
+
+// MockLeakyBucketAdapter is a mock of LeakyBucketAdapter interface.
+type MockLeakyBucketAdapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeakyBucketAdapterMockRecorder
+}
+
+// MockLeakyBucketAdapterMockRecorder is the mock recorder for MockLeakyBucketAdapter.
+type MockLeakyBucketAdapterMockRecorder struct {
+	mock *MockLeakyBucketAdapter
+}
+
+// NewMockLeakyBucketAdapter creates a new mock instance.
+func NewMockLeakyBucketAdapter(ctrl *gomock.Controller) *MockLeakyBucketAdapter {
+	mock := &MockLeakyBucketAdapter{ctrl: ctrl}
+	mock.recorder = &MockLeakyBucketAdapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeakyBucketAdapter) EXPECT() *MockLeakyBucketAdapterMockRecorder {
+	return m.recorder
+}
+
+// Schedule mocks base method.
+func (m *MockLeakyBucketAdapter) Schedule(ctx context.Context, key string, now time.Time, increment, maxDelay time.Duration) (time.Time, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Schedule", ctx, key, now, increment, maxDelay)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Schedule indicates an expected call of Schedule.
+func (mr *MockLeakyBucketAdapterMockRecorder) Schedule(ctx, key, now, increment, maxDelay interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Schedule", reflect.TypeOf((*MockLeakyBucketAdapter)(nil).Schedule), ctx, key, now, increment, maxDelay)
+}
+
+// MockLogAdapter is a mock of LogAdapter interface.
+type MockLogAdapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLogAdapterMockRecorder
+}
+
+// MockLogAdapterMockRecorder is the mock recorder for MockLogAdapter.
+type MockLogAdapterMockRecorder struct {
+	mock *MockLogAdapter
+}
+
+// NewMockLogAdapter creates a new mock instance.
+func NewMockLogAdapter(ctrl *gomock.Controller) *MockLogAdapter {
+	mock := &MockLogAdapter{ctrl: ctrl}
+	mock.recorder = &MockLogAdapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLogAdapter) EXPECT() *MockLogAdapterMockRecorder {
+	return m.recorder
+}
+
+// AppendLog mocks base method.
+func (m *MockLogAdapter) AppendLog(ctx context.Context, key string, entry limiter.LogEntry, retention time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendLog", ctx, key, entry, retention)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendLog indicates an expected call of AppendLog.
+func (mr *MockLogAdapterMockRecorder) AppendLog(ctx, key, entry, retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendLog", reflect.TypeOf((*MockLogAdapter)(nil).AppendLog), ctx, key, entry, retention)
+}
+
+// ReadLog mocks base method.
+func (m *MockLogAdapter) ReadLog(ctx context.Context, key string, since, until time.Time) ([]limiter.LogEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadLog", ctx, key, since, until)
+	ret0, _ := ret[0].([]limiter.LogEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadLog indicates an expected call of ReadLog.
+func (mr *MockLogAdapterMockRecorder) ReadLog(ctx, key, since, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadLog", reflect.TypeOf((*MockLogAdapter)(nil).ReadLog), ctx, key, since, until)
+}
@@ -0,0 +1,169 @@
+package limiter
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithMetrics reports the limiter decisions and adapter calls to m.
+func WithMetrics(m Metrics) Option {
+	return func(l *Limiter) {
+		l.metrics = m
+	}
+}
+
+// WithTracer traces the Record and Check calls with t.
+func WithTracer(t Tracer) Option {
+	return func(l *Limiter) {
+		l.tracer = t
+	}
+}
+
+// WithLogger logs the denials at info level, the soft limit warnings and fail open fallbacks at warn level
+// and the adapter errors at error level to logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(l *Limiter) {
+		l.logger = logger
+	}
+}
+
+// WithLogSampling samples the logs of WithLogger per metric and kind of record.
+// Within every interval the first records are logged, then only every thereafter-th one.
+// A thereafter of zero drops every record after the first ones.
+func WithLogSampling(interval time.Duration, first, thereafter int) Option {
+	return func(l *Limiter) {
+		l.sampler = newLogSampler(interval, first, thereafter)
+	}
+}
+
+// WithHook calls hook on the threshold crossings and limit denials of every metric.
+// Each event fires at most once per subject and window of its duration.
+func WithHook(hook Hook) Option {
+	return func(l *Limiter) {
+		l.hooks.hooks = append(l.hooks.hooks, hook)
+	}
+}
+
+// WithThresholds sets the soft thresholds of the metric and duration reported to the hooks,
+// as fractions of the limit, e.g. 0.8 to be notified at 80% of the limit.
+func WithThresholds(metric string, duration Duration, thresholds ...float64) Option {
+	return func(l *Limiter) {
+		if l.hooks.thresholds[metric] == nil {
+			l.hooks.thresholds[metric] = make(map[Duration][]float64)
+		}
+		l.hooks.thresholds[metric][duration] = append(l.hooks.thresholds[metric][duration], thresholds...)
+	}
+}
+
+// WithSoftLimits sets the soft limits of the metric per evaluation duration.
+// Going over a soft limit is reported as StatusWarning by Evaluate while the check is still allowed.
+func WithSoftLimits(metric string, limits Limits) Option {
+	return func(l *Limiter) {
+		l.table.Load().softLimits[metric] = limits.clone()
+	}
+}
+
+// WithShadow puts the metrics in shadow mode.
+// Their checks are evaluated and reported to the metrics, hooks, logs and traces as usual, but are always allowed.
+func WithShadow(metrics ...string) Option {
+	return func(l *Limiter) {
+		for _, metric := range metrics {
+			l.table.Load().shadow[metric] = true
+		}
+	}
+}
+
+// WithFailPolicy sets the policy applied to the metric when the adapter fails, FailClosed by default.
+func WithFailPolicy(metric string, policy FailPolicy) Option {
+	return func(l *Limiter) {
+		l.table.Load().failPolicy[metric] = policy
+	}
+}
+
+// WithAlgorithm sets the algorithm counting the usage of the metric, AlgorithmSlidingWindow by default.
+func WithAlgorithm(metric string, a Algorithm) Option {
+	return func(l *Limiter) {
+		l.table.Load().algorithms[metric] = a
+	}
+}
+
+// WithConcurrency caps the number of leases of the metric held at once by a subject.
+// The leases expire after ttl unless renewed, so the slots of crashed holders are freed.
+func WithConcurrency(metric string, limit int64, ttl time.Duration) Option {
+	return func(l *Limiter) {
+		l.table.Load().concurrency[metric] = Concurrency{Limit: limit, TTL: ttl}
+	}
+}
+
+// WithPriorities reserves headroom of the metric limits for the higher priorities.
+// shares is the fraction of the limits each priority may use when checked with CheckPriority,
+// the priorities missing from shares use the full limits.
+func WithPriorities(metric string, shares map[Priority]float64) Option {
+	return func(l *Limiter) {
+		t := l.table.Load()
+		t.priorities[metric] = make(map[Priority]float64, len(shares))
+		for priority, share := range shares {
+			t.priorities[metric][priority] = share
+		}
+	}
+}
+
+// WithAdaptive adjusts the limit of the metric for a.Duration to the outcomes reported with Report,
+// starting from its configured limit.
+// It panics if the max is not positive, i.e. neither Max, Min nor the configured limit is.
+func WithAdaptive(metric string, a Adaptive) Option {
+	return func(l *Limiter) {
+		t := l.table.Load()
+		al := newAdaptiveLimit(a, t.limits[metric][a.Duration])
+		if al.Max <= 0 {
+			panic(fmt.Sprintf("limiter: adaptive limit of %s must have a positive max, got %d", metric, al.Max))
+		}
+		t.adaptive[metric] = al
+	}
+}
+
+// WithPenalty bans the subjects denied repeatedly on the metric, see Penalty.
+// It panics if p.Ban is not positive, like time.NewTicker for a non-positive duration.
+func WithPenalty(metric string, p Penalty) Option {
+	if p.Ban <= 0 {
+		panic(fmt.Sprintf("limiter: penalty of %s must ban for a positive period, got %s", metric, p.Ban))
+	}
+
+	return func(l *Limiter) {
+		l.table.Load().penalties[metric] = p.withDefaults()
+	}
+}
+
+// WithAllowlist lets the subjects of the list bypass the limits, see SetAllowlist.
+func WithAllowlist(list *SubjectList) Option {
+	return func(l *Limiter) {
+		l.table.Load().allowlist = list
+	}
+}
+
+// WithDenylist denies the subjects of the list outright, see SetDenylist.
+func WithDenylist(list *SubjectList) Option {
+	return func(l *Limiter) {
+		l.table.Load().denylist = list
+	}
+}
+
+// WithLeakyBucket schedules the requests of the metric at the constant rate of the bucket, see Schedule.
+// Rate and Per must be positive, Schedule fails otherwise.
+func WithLeakyBucket(metric string, b LeakyBucket) Option {
+	return func(l *Limiter) {
+		l.table.Load().leakyBuckets[metric] = b
+	}
+}
+
+// WithLimitProvider resolves the limits of every subject with provider, falling back to the metric defaults.
+// The resolved overrides are cached locally for ttl, a ttl of zero disables the cache.
+func WithLimitProvider(provider LimitProvider, ttl time.Duration) Option {
+	return func(l *Limiter) {
+		l.overrides = newOverrideCache(provider, ttl)
+	}
+}
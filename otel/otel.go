@@ -0,0 +1,220 @@
+// Package otel traces the limiter operations and adapter calls with OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+const instrumentationName = "github.com/hendrywiranto/limiter"
+
+// Tracer is a limiter.Tracer backed by an OpenTelemetry tracer.
+// Pass it to limiter.WithTracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ limiter.Tracer = (*Tracer)(nil)
+
+// NewTracer returns a new Tracer instance creating its spans from tp.
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+// StartSpan implements limiter.Tracer.
+func (t *Tracer) StartSpan(ctx context.Context, name string) (context.Context, limiter.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal))
+	return ctx, &spanAdapter{span: span}
+}
+
+type spanAdapter struct {
+	span trace.Span
+}
+
+func (s *spanAdapter) SetAttribute(key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	}
+}
+
+func (s *spanAdapter) End(err error) {
+	endSpan(s.span, err)
+}
+
+// Adapter traces every call to the wrapped adapter.
+type Adapter struct {
+	adapter limiter.Adapter
+	tracer  trace.Tracer
+}
+
+var (
+	_ limiter.Adapter            = (*Adapter)(nil)
+	_ limiter.BatchAdapter       = (*Adapter)(nil)
+	_ limiter.AtomicAdapter      = (*Adapter)(nil)
+	_ limiter.LeaseAdapter       = (*Adapter)(nil)
+	_ limiter.LeakyBucketAdapter = (*Adapter)(nil)
+	_ limiter.LogAdapter         = (*Adapter)(nil)
+	_ limiter.ExpiringAdapter    = (*Adapter)(nil)
+)
+
+// NewAdapter returns a new Adapter instance wrapping adapter and creating its spans from tp.
+func NewAdapter(adapter limiter.Adapter, tp trace.TracerProvider) *Adapter {
+	return &Adapter{
+		adapter: adapter,
+		tracer:  tp.Tracer(instrumentationName),
+	}
+}
+
+func (a *Adapter) Get(ctx context.Context, key string, value interface{}) error {
+	ctx, span := a.start(ctx, "Get")
+	err := a.adapter.Get(ctx, key, value)
+	if errors.Is(err, limiter.ErrCacheMiss) {
+		// a cache miss is an expected outcome rather than a failure.
+		endSpan(span, nil)
+		return err
+	}
+	endSpan(span, err)
+
+	return err
+}
+
+func (a *Adapter) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	ctx, span := a.start(ctx, "Set")
+	err := a.adapter.Set(ctx, key, value, expiration)
+	endSpan(span, err)
+
+	return err
+}
+
+func (a *Adapter) IncrBy(ctx context.Context, key string, value int64) error {
+	ctx, span := a.start(ctx, "IncrBy")
+	span.SetAttributes(attribute.Int64(limiter.AttributeValue, value))
+	err := a.adapter.IncrBy(ctx, key, value)
+	endSpan(span, err)
+
+	return err
+}
+
+func (a *Adapter) SumKeys(ctx context.Context, keys []string) (int64, error) {
+	ctx, span := a.start(ctx, "SumKeys")
+	span.SetAttributes(attribute.Int(limiter.AttributeKeyCount, len(keys)))
+	sum, err := a.adapter.SumKeys(ctx, keys)
+	endSpan(span, err)
+
+	return sum, err
+}
+
+func (a *Adapter) IncrByKeys(ctx context.Context, keys []string, value int64) error {
+	ctx, span := a.start(ctx, "IncrByKeys")
+	span.SetAttributes(attribute.Int(limiter.AttributeKeyCount, len(keys)), attribute.Int64(limiter.AttributeValue, value))
+	err := limiter.IncrByKeys(ctx, a.adapter, keys, value)
+	endSpan(span, err)
+
+	return err
+}
+
+func (a *Adapter) SumKeyGroups(ctx context.Context, groups [][]string) ([]int64, error) {
+	ctx, span := a.start(ctx, "SumKeyGroups")
+	count := 0
+	for _, keys := range groups {
+		count += len(keys)
+	}
+	span.SetAttributes(attribute.Int(limiter.AttributeKeyCount, count))
+	sums, err := limiter.SumKeyGroups(ctx, a.adapter, groups)
+	endSpan(span, err)
+
+	return sums, err
+}
+
+func (a *Adapter) IncrIf(ctx context.Context, conditions []limiter.Condition, increments []limiter.Increment) (int, int64, error) {
+	ctx, span := a.start(ctx, "IncrIf")
+	span.SetAttributes(attribute.Int(limiter.AttributeKeyCount, len(increments)))
+	failed, sum, err := limiter.IncrIf(ctx, a.adapter, conditions, increments)
+	endSpan(span, err)
+
+	return failed, sum, err
+}
+
+func (a *Adapter) AcquireLease(ctx context.Context, key, id string, limit int64, now time.Time, ttl time.Duration) (bool, error) {
+	ctx, span := a.start(ctx, "AcquireLease")
+	ok, err := limiter.AcquireLease(ctx, a.adapter, key, id, limit, now, ttl)
+	endSpan(span, err)
+
+	return ok, err
+}
+
+func (a *Adapter) RenewLease(ctx context.Context, key, id string, now time.Time, ttl time.Duration) (bool, error) {
+	ctx, span := a.start(ctx, "RenewLease")
+	ok, err := limiter.RenewLease(ctx, a.adapter, key, id, now, ttl)
+	endSpan(span, err)
+
+	return ok, err
+}
+
+func (a *Adapter) ReleaseLease(ctx context.Context, key, id string) error {
+	ctx, span := a.start(ctx, "ReleaseLease")
+	err := limiter.ReleaseLease(ctx, a.adapter, key, id)
+	endSpan(span, err)
+
+	return err
+}
+
+func (a *Adapter) Schedule(ctx context.Context, key string, now time.Time, increment, maxDelay time.Duration) (time.Time, bool, error) {
+	ctx, span := a.start(ctx, "Schedule")
+	at, ok, err := limiter.Schedule(ctx, a.adapter, key, now, increment, maxDelay)
+	endSpan(span, err)
+
+	return at, ok, err
+}
+
+func (a *Adapter) AppendLog(ctx context.Context, key string, entry limiter.LogEntry, retention time.Duration) error {
+	ctx, span := a.start(ctx, "AppendLog")
+	err := limiter.AppendLog(ctx, a.adapter, key, entry, retention)
+	endSpan(span, err)
+
+	return err
+}
+
+func (a *Adapter) ReadLog(ctx context.Context, key string, since, until time.Time) ([]limiter.LogEntry, error) {
+	ctx, span := a.start(ctx, "ReadLog")
+	entries, err := limiter.ReadLog(ctx, a.adapter, key, since, until)
+	endSpan(span, err)
+
+	return entries, err
+}
+
+func (a *Adapter) IncrByExpire(ctx context.Context, key string, value int64, expiration time.Duration) (int64, error) {
+	ctx, span := a.start(ctx, "IncrByExpire")
+	span.SetAttributes(attribute.Int64(limiter.AttributeValue, value))
+	current, err := limiter.IncrByExpire(ctx, a.adapter, key, value, expiration)
+	endSpan(span, err)
+
+	return current, err
+}
+
+func (a *Adapter) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return a.tracer.Start(ctx, "limiter.adapter."+operation, trace.WithSpanKind(trace.SpanKindClient))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
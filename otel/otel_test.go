@@ -0,0 +1,112 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/mock"
+	"github.com/hendrywiranto/limiter/otel"
+)
+
+type OtelSuite struct {
+	suite.Suite
+
+	ctx      context.Context
+	exporter *tracetest.InMemoryExporter
+	adapter  *mock.MockAdapter
+	l        *limiter.Limiter
+}
+
+func TestOtel(t *testing.T) {
+	suite.Run(t, new(OtelSuite))
+}
+
+func (s *OtelSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.exporter = tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.exporter))
+
+	s.adapter = mock.NewMockAdapter(gomock.NewController(s.T()))
+	limits := map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}
+	s.l = limiter.New(otel.NewAdapter(s.adapter, tp), limits, limiter.WithTracer(otel.NewTracer(tp)))
+
+	// mock the current time to 2024-02-29 23:11:11 UTC.
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	}
+}
+
+func (s *OtelSuite) attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+
+	return attrs
+}
+
+func (s *OtelSuite) TestCheckDenied() {
+	s.adapter.EXPECT().SumKeys(gomock.Any(), []string{"metric_test:user1:20240229231110"}).Return(int64(6), nil)
+
+	err := s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.Require().ErrorIs(err, limiter.ErrLimitExceeded)
+
+	spans := s.exporter.GetSpans()
+	s.Require().Len(spans, 2)
+
+	adapterSpan, checkSpan := spans[0], spans[1]
+	s.Equal("limiter.adapter.SumKeys", adapterSpan.Name)
+	s.Equal(checkSpan.SpanContext.SpanID(), adapterSpan.Parent.SpanID())
+	s.Equal(int64(1), s.attributes(adapterSpan)[limiter.AttributeKeyCount].AsInt64())
+
+	s.Equal("limiter.Check", checkSpan.Name)
+	s.Equal(codes.Unset, checkSpan.Status.Code)
+	attrs := s.attributes(checkSpan)
+	s.Equal("metric_test", attrs[limiter.AttributeMetric].AsString())
+	s.Equal("user1", attrs[limiter.AttributeSubject].AsString())
+	s.Equal("second", attrs[limiter.AttributeDuration].AsString())
+	s.Equal(int64(1), attrs[limiter.AttributeKeyCount].AsInt64())
+	s.Equal(limiter.DecisionDenied, attrs[limiter.AttributeDecision].AsString())
+}
+
+func (s *OtelSuite) TestRecordFailed() {
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().IncrBy(gomock.Any(), "metric_test:20240229231111", int64(10)).Return(mockedErr)
+
+	err := s.l.Record(s.ctx, "metric_test", 10)
+	s.Require().ErrorIs(err, mockedErr)
+
+	spans := s.exporter.GetSpans()
+	s.Require().Len(spans, 2)
+	s.Equal("limiter.adapter.IncrBy", spans[0].Name)
+	s.Equal(codes.Error, spans[0].Status.Code)
+	s.Equal("limiter.Record", spans[1].Name)
+	s.Equal(codes.Error, spans[1].Status.Code)
+	s.Equal(int64(10), s.attributes(spans[1])[limiter.AttributeValue].AsInt64())
+}
+
+func (s *OtelSuite) TestAdapterGetCacheMiss() {
+	s.adapter.EXPECT().Get(gomock.Any(), "mykey", gomock.Any()).Return(limiter.ErrCacheMiss)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.exporter))
+
+	var value int64
+	err := otel.NewAdapter(s.adapter, tp).Get(s.ctx, "mykey", &value)
+	s.Require().ErrorIs(err, limiter.ErrCacheMiss)
+
+	spans := s.exporter.GetSpans()
+	s.Require().Len(spans, 1)
+	s.Equal("limiter.adapter.Get", spans[0].Name)
+	s.Equal(codes.Unset, spans[0].Status.Code)
+}
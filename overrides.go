@@ -0,0 +1,154 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LimitProvider resolves the limits overriding the metric defaults for a subject,
+// e.g. the higher quotas of enterprise customers.
+type LimitProvider interface {
+	// SubjectLimits returns the override of the subject and whether there is one.
+	// The durations missing from the override keep the metric default limits.
+	SubjectLimits(ctx context.Context, metric, subject string) (Limits, bool, error)
+}
+
+// StaticOverrides is a LimitProvider backed by a map of metric name, subject and limits.
+type StaticOverrides map[string]map[string]Limits
+
+// SubjectLimits implements LimitProvider.
+func (o StaticOverrides) SubjectLimits(_ context.Context, metric, subject string) (Limits, bool, error) {
+	limits, ok := o[metric][subject]
+	return limits, ok, nil
+}
+
+// AdapterOverrides is a LimitProvider storing the overrides in an Adapter,
+// so they are shared by every instance using the same storage.
+type AdapterOverrides struct {
+	adapter Adapter
+}
+
+// NewAdapterOverrides returns a new AdapterOverrides instance.
+func NewAdapterOverrides(adapter Adapter) *AdapterOverrides {
+	return &AdapterOverrides{adapter: adapter}
+}
+
+// SubjectLimits implements LimitProvider.
+func (o *AdapterOverrides) SubjectLimits(ctx context.Context, metric, subject string) (Limits, bool, error) {
+	var limits Limits
+	if err := o.adapter.Get(ctx, overrideKey(metric, subject), &limits); err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	return limits, true, nil
+}
+
+// Set stores the override of the subject, expiration of zero keeps it forever.
+func (o *AdapterOverrides) Set(ctx context.Context, metric, subject string, limits Limits, expiration time.Duration) error {
+	return o.adapter.Set(ctx, overrideKey(metric, subject), limits, expiration)
+}
+
+func overrideKey(metric, subject string) string {
+	return fmt.Sprintf("override:%s", keyPrefix(metric, subject))
+}
+
+// overrideCache caches the overrides resolved by a LimitProvider, including the subjects without one.
+type overrideCache struct {
+	provider LimitProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]overrideEntry
+}
+
+type overrideEntry struct {
+	limits    Limits
+	ok        bool
+	expiresAt time.Time
+}
+
+func newOverrideCache(provider LimitProvider, ttl time.Duration) *overrideCache {
+	return &overrideCache{
+		provider: provider,
+		ttl:      ttl,
+		entries:  make(map[string]overrideEntry),
+	}
+}
+
+func (c *overrideCache) get(ctx context.Context, metric, subject string) (Limits, bool, error) {
+	if c.ttl <= 0 {
+		return c.provider.SubjectLimits(ctx, metric, subject)
+	}
+
+	key := keyPrefix(metric, subject)
+	now := Now()
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	c.mu.Unlock()
+	if found && now.Before(entry.expiresAt) {
+		return entry.limits, entry.ok, nil
+	}
+
+	limits, ok, err := c.provider.SubjectLimits(ctx, metric, subject)
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.mu.Lock()
+	c.evict(now)
+	c.entries[key] = overrideEntry{limits: limits, ok: ok, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return limits, ok, nil
+}
+
+// evict drops the expired entries once the cache has grown.
+// The caller must hold the lock.
+func (c *overrideCache) evict(now time.Time) {
+	const maxEntries = 10000
+	if len(c.entries) < maxEntries {
+		return
+	}
+
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// subjectLimits returns the limits of the metric for the subject, applying its override if any.
+// The metric defaults, adjusted by the adaptive limit if any, are used when the override cannot be resolved.
+func (l *Limiter) subjectLimits(ctx context.Context, t *table, metric, subject string) Limits {
+	limits := t.adaptiveLimits(metric, t.limits[metric])
+	if l.overrides == nil || subject == "" {
+		return limits
+	}
+
+	override, ok, err := l.overrides.get(ctx, metric, subject)
+	if err != nil {
+		l.logProviderError(ctx, metric, subject, err)
+		return limits
+	}
+	if !ok {
+		return limits
+	}
+
+	merged := limits.clone()
+	if merged == nil {
+		merged = make(Limits, len(override))
+	}
+	for duration, limit := range override {
+		merged[duration] = limit
+	}
+
+	return merged
+}
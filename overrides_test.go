@@ -0,0 +1,106 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+type countingProvider struct {
+	limiter.LimitProvider
+	calls int
+}
+
+func (p *countingProvider) SubjectLimits(ctx context.Context, metric, subject string) (limiter.Limits, bool, error) {
+	p.calls++
+	return p.LimitProvider.SubjectLimits(ctx, metric, subject)
+}
+
+func (s *LimiterSuite) TestStaticOverrides() {
+	overrides := limiter.StaticOverrides{
+		"metric_test": {"enterprise": {limiter.DurationSecond: 50}},
+	}
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5, limiter.DurationMinute: 10},
+	}, limiter.WithLimitProvider(overrides, 0))
+
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:enterprise:20240229231110"}).Return(int64(20), nil)
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "enterprise", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.StatusOK, res.Status)
+	s.Equal(int64(50), res.Limit)
+
+	// the durations without override keep the metric default.
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(20), nil)
+	res, err = s.l.Evaluate(s.ctx, "metric_test", "enterprise", limiter.DurationMinute)
+	s.NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.Equal(int64(10), res.Limit)
+
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:free:20240229231110"}).Return(int64(20), nil)
+	res, err = s.l.Evaluate(s.ctx, "metric_test", "free", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.Equal(int64(5), res.Limit)
+}
+
+func (s *LimiterSuite) TestOverridesCached() {
+	provider := &countingProvider{LimitProvider: limiter.StaticOverrides{
+		"metric_test": {"enterprise": {limiter.DurationSecond: 50}},
+	}}
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}, limiter.WithLimitProvider(provider, time.Minute))
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), nil).Times(4)
+
+	for i := 0; i < 2; i++ {
+		s.NoError(s.l.CheckFor(s.ctx, "metric_test", "enterprise", limiter.DurationSecond))
+		s.NoError(s.l.CheckFor(s.ctx, "metric_test", "free", limiter.DurationSecond))
+	}
+	s.Equal(2, provider.calls)
+
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 12, 11, 0, time.UTC)
+	}
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), nil)
+	s.NoError(s.l.CheckFor(s.ctx, "metric_test", "enterprise", limiter.DurationSecond))
+	s.Equal(3, provider.calls)
+}
+
+func (s *LimiterSuite) TestOverridesProviderError() {
+	s.adapter.EXPECT().Get(s.ctx, "override:metric_test:user1", gomock.Any()).Return(errors.New("mocked error"))
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(6), nil)
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}, limiter.WithLimitProvider(limiter.NewAdapterOverrides(s.adapter), time.Minute))
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(int64(5), res.Limit)
+}
+
+func (s *LimiterSuite) TestAdapterOverrides() {
+	adapter := memory.NewAdapter()
+	overrides := limiter.NewAdapterOverrides(adapter)
+	s.Require().NoError(overrides.Set(s.ctx, "metric_test", "enterprise", limiter.Limits{limiter.DurationSecond: 50}, 0))
+
+	limits, ok, err := overrides.SubjectLimits(s.ctx, "metric_test", "enterprise")
+	s.NoError(err)
+	s.True(ok)
+	s.Equal(limiter.Limits{limiter.DurationSecond: 50}, limits)
+
+	_, ok, err = overrides.SubjectLimits(s.ctx, "metric_test", "free")
+	s.NoError(err)
+	s.False(ok)
+
+	l := limiter.New(adapter, map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}, limiter.WithLimitProvider(overrides, time.Minute))
+	s.Require().NoError(adapter.IncrBy(s.ctx, "metric_test:enterprise:20240229231110", 20))
+	s.NoError(l.CheckFor(s.ctx, "metric_test", "enterprise", limiter.DurationSecond))
+}
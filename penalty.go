@@ -0,0 +1,142 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Penalty bans a subject for an escalating period once it has been denied repeatedly.
+// A banned subject is denied with a single key lookup, before any window is summed,
+// by the checks, transactions, waits, reservations, queues and hierarchy checks.
+// Like the other limits, the ban does not stop the plain records.
+type Penalty struct {
+	// Violations is the number of denials within Window triggering a ban.
+	Violations int64
+	// Window is the period the denials are counted over.
+	Window time.Duration
+	// Ban is the period of the first ban, it must be positive.
+	Ban time.Duration
+	// Escalation multiplies the period of every later ban, 2 by default.
+	Escalation float64
+	// MaxBan caps the period of the bans, a year by default.
+	MaxBan time.Duration
+	// Reset is the period without a ban after which the bans escalate from the start again, 24 hours by default.
+	Reset time.Duration
+}
+
+// defaultMaxBan caps the bans escalating without a MaxBan, so their period cannot overflow.
+const defaultMaxBan = 365 * 24 * time.Hour
+
+func (p Penalty) withDefaults() Penalty {
+	if p.Escalation < 1 {
+		p.Escalation = 2
+	}
+	if p.MaxBan <= 0 {
+		p.MaxBan = defaultMaxBan
+	}
+	if p.Reset <= 0 {
+		p.Reset = 24 * time.Hour
+	}
+
+	return p
+}
+
+// ban returns the period of the ban of the given level, starting at zero.
+// The period is computed as a float so it is capped by MaxBan before it could overflow.
+func (p Penalty) ban(level int) time.Duration {
+	ban := float64(p.Ban) * math.Pow(p.Escalation, float64(level))
+	if ban >= float64(p.MaxBan) {
+		return p.MaxBan
+	}
+
+	return time.Duration(ban)
+}
+
+// penaltyRecord is the ban state of a subject, stored at its penalty key.
+type penaltyRecord struct {
+	// Level is the number of bans since the last reset.
+	Level int       `json:"level"`
+	Until time.Time `json:"until"`
+}
+
+// bannedUntil returns the end of the ban of the subject, zero if it is not banned.
+// The subjects are not banned when the penalty state cannot be read.
+func (l *Limiter) bannedUntil(ctx context.Context, t *table, metric, subject string) time.Time {
+	if _, ok := t.penalties[metric]; !ok || subject == "" || t.shadow[metric] {
+		return time.Time{}
+	}
+
+	record, err := l.penaltyRecord(ctx, metric, subject)
+	if err != nil {
+		l.logAdapterError(ctx, OperationGet, metric, subject, err)
+		return time.Time{}
+	}
+
+	if !Now().Before(record.Until) {
+		return time.Time{}
+	}
+
+	return record.Until
+}
+
+// errBanned is returned for the banned subjects.
+func errBanned(until time.Time) error {
+	return fmt.Errorf("%w: banned until %s", ErrLimitExceeded, until.Format(time.RFC3339))
+}
+
+// penalize counts a denial of the subject and bans it once it reaches the violations of the penalty.
+func (l *Limiter) penalize(ctx context.Context, t *table, metric, subject string) {
+	p, ok := t.penalties[metric]
+	if !ok || subject == "" || p.Violations <= 0 || p.Window <= 0 {
+		return
+	}
+
+	record, err := l.penaltyRecord(ctx, metric, subject)
+	if err != nil {
+		l.logAdapterError(ctx, OperationGet, metric, subject, err)
+		return
+	}
+
+	// the denials are counted per level so they start over once banned,
+	// the counter of a window expiring with it.
+	now := Now()
+	key := fmt.Sprintf("%s:%d:%d", penaltyKey(metric, subject), record.Level, now.UnixNano()/int64(p.Window))
+	violations, err := IncrByExpire(ctx, l.adapter, key, 1, p.Window)
+	if err != nil {
+		l.logAdapterError(ctx, OperationIncrByExpire, metric, subject, err)
+		return
+	}
+	if violations < p.Violations {
+		return
+	}
+
+	ban := p.ban(record.Level)
+	record = penaltyRecord{Level: record.Level + 1, Until: now.Add(ban)}
+	if err := l.adapter.Set(ctx, penaltyKey(metric, subject), record, ban+p.Reset); err != nil {
+		l.logAdapterError(ctx, OperationSet, metric, subject, err)
+		return
+	}
+
+	l.log(ctx, slog.LevelWarn, "limiter: subject banned", metric,
+		slog.String("subject", subject),
+		slog.Int("level", record.Level),
+		slog.Duration("ban", ban),
+	)
+}
+
+func (l *Limiter) penaltyRecord(ctx context.Context, metric, subject string) (penaltyRecord, error) {
+	var record penaltyRecord
+	if err := l.adapter.Get(ctx, penaltyKey(metric, subject), &record); err != nil && !errors.Is(err, ErrCacheMiss) {
+		return penaltyRecord{}, err
+	}
+
+	return record, nil
+}
+
+func penaltyKey(metric, subject string) string {
+	return fmt.Sprintf("penalty:%s", keyPrefix(metric, subject))
+}
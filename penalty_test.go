@@ -0,0 +1,189 @@
+package limiter_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+// countingAdapter counts the window summations.
+type countingAdapter struct {
+	*memory.Adapter
+	sums int
+}
+
+func (a *countingAdapter) SumKeys(ctx context.Context, keys []string) (int64, error) {
+	a.sums++
+	return a.Adapter.SumKeys(ctx, keys)
+}
+
+var (
+	penaltyLimits = map[string]limiter.Limits{"logins": {limiter.DurationSecond: 1}}
+	penaltyOption = limiter.WithPenalty("logins", limiter.Penalty{
+		Violations: 2,
+		Window:     time.Minute,
+		Ban:        time.Minute,
+		MaxBan:     3 * time.Minute,
+	})
+)
+
+func (s *LimiterSuite) setNow(now time.Time) {
+	limiter.Now = func() time.Time { return now }
+}
+
+func (s *LimiterSuite) TestPenaltyBan() {
+	adapter := &countingAdapter{Adapter: memory.NewAdapter()}
+	s.newLimiter(adapter, penaltyLimits, penaltyOption)
+	s.Require().NoError(adapter.IncrBy(s.ctx, "logins:42:20240229231110", 5))
+
+	for i := 0; i < 2; i++ {
+		err := s.l.CheckFor(s.ctx, "logins", "42", limiter.DurationSecond)
+		s.Equal(limiter.ErrLimitExceeded, err)
+	}
+
+	sums := adapter.sums
+	res, err := s.l.Evaluate(s.ctx, "logins", "42", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 11, 0, time.UTC), res.BannedUntil)
+	s.Equal(sums, adapter.sums, "a banned subject is denied without summing its windows")
+
+	err = s.l.CheckFor(s.ctx, "logins", "42", limiter.DurationSecond)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "banned until 2024-02-29T23:12:11Z")
+
+	err = s.l.CheckFor(s.ctx, "logins", "43", limiter.DurationSecond)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestPenaltyEscalation() {
+	adapter := &countingAdapter{Adapter: memory.NewAdapter()}
+	s.newLimiter(adapter, penaltyLimits, penaltyOption)
+	deny := func(now time.Time) {
+		s.setNow(now)
+		s.Require().NoError(adapter.IncrBy(s.ctx, "logins:42:"+now.Add(-time.Second).Format("20060102150405"), 5))
+		for i := 0; i < 2; i++ {
+			s.Require().ErrorIs(s.l.CheckFor(s.ctx, "logins", "42", limiter.DurationSecond), limiter.ErrLimitExceeded)
+		}
+	}
+	bannedUntil := func() time.Time {
+		res, err := s.l.Evaluate(s.ctx, "logins", "42", limiter.DurationSecond)
+		s.Require().NoError(err)
+		return res.BannedUntil
+	}
+
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	deny(now)
+	s.Equal(now.Add(time.Minute), bannedUntil())
+
+	now = now.Add(2 * time.Minute)
+	deny(now)
+	s.Equal(now.Add(2*time.Minute), bannedUntil())
+
+	now = now.Add(3 * time.Minute)
+	deny(now)
+	s.Equal(now.Add(3*time.Minute), bannedUntil(), "capped by the max ban")
+}
+
+func (s *LimiterSuite) TestPenaltyShadow() {
+	adapter := &countingAdapter{Adapter: memory.NewAdapter()}
+	s.newLimiter(adapter, penaltyLimits,
+		limiter.WithShadow("logins"),
+		limiter.WithPenalty("logins", limiter.Penalty{Violations: 1, Window: time.Minute, Ban: time.Minute}))
+	s.Require().NoError(adapter.IncrBy(s.ctx, "logins:42:20240229231110", 5))
+
+	for i := 0; i < 3; i++ {
+		res, err := s.l.Evaluate(s.ctx, "logins", "42", limiter.DurationSecond)
+		s.Require().NoError(err)
+		s.True(res.BannedUntil.IsZero())
+	}
+}
+
+func (s *LimiterSuite) TestPenaltyBanEnforcedEverywhere() {
+	adapter := &countingAdapter{Adapter: memory.NewAdapter()}
+	s.newLimiter(adapter, penaltyLimits,
+		limiter.WithPenalty("logins", limiter.Penalty{Violations: 1, Window: time.Minute, Ban: time.Minute}),
+		limiter.WithHierarchy("accounts", "logins"))
+	s.Require().NoError(adapter.IncrBy(s.ctx, "logins:42:20240229231110", 5))
+	s.Require().ErrorIs(s.l.CheckFor(s.ctx, "logins", "42", limiter.DurationSecond), limiter.ErrLimitExceeded)
+
+	// the usage has left the window, but the subject is still banned.
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 30, 0, time.UTC))
+	sums := adapter.sums
+
+	_, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "logins", Value: 1})
+	s.ErrorContains(err, "banned until 2024-02-29T23:12:11Z")
+	s.ErrorContains(s.l.WaitFor(s.ctx, "logins", "42", 1), "banned until")
+	_, err = s.l.Reserve(s.ctx, "logins", "42", 1)
+	s.ErrorContains(err, "banned until")
+
+	results, err := s.l.EvaluateHierarchy(s.ctx, "accounts", []string{"42"}, limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 11, 0, time.UTC), results[0].BannedUntil)
+	s.ErrorIs(s.l.CheckHierarchy(s.ctx, "accounts", []string{"42"}, limiter.DurationSecond), limiter.ErrLimitExceeded)
+	s.Equal(sums, adapter.sums, "a banned subject is denied without summing its windows")
+
+	_, err = s.l.RecordAll(s.ctx, "43", limiter.Cost{Metric: "logins", Value: 1})
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestPenaltyViolationsExpire() {
+	adapter := &countingAdapter{Adapter: memory.NewAdapter()}
+	s.newLimiter(adapter, penaltyLimits, penaltyOption)
+	s.Require().NoError(adapter.IncrBy(s.ctx, "logins:42:20240229231110", 5))
+	s.Require().ErrorIs(s.l.CheckFor(s.ctx, "logins", "42", limiter.DurationSecond), limiter.ErrLimitExceeded)
+
+	key := fmt.Sprintf("penalty:logins:42:0:%d", limiter.Now().UnixNano()/int64(time.Minute))
+	var violations int64
+	s.Require().NoError(adapter.Get(s.ctx, key, &violations))
+	s.Equal(int64(1), violations)
+
+	// the violations of a window are dropped with it.
+	s.setNow(limiter.Now().Add(time.Minute))
+	s.ErrorIs(adapter.Get(s.ctx, key, &violations), limiter.ErrCacheMiss)
+}
+
+func (s *LimiterSuite) TestPenaltyViolationsFallback() {
+	s.newLimiter(s.adapter, penaltyLimits,
+		limiter.WithPenalty("logins", limiter.Penalty{Violations: 2, Window: time.Minute, Ban: time.Minute}))
+	key := fmt.Sprintf("penalty:logins:42:0:%d", limiter.Now().UnixNano()/int64(time.Minute))
+	s.adapter.EXPECT().Get(s.ctx, "penalty:logins:42", gomock.Any()).Return(limiter.ErrCacheMiss).Times(2)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"logins:42:20240229231110"}).Return(int64(5), nil)
+	s.adapter.EXPECT().Get(s.ctx, key, gomock.Any()).Return(limiter.ErrCacheMiss)
+	s.adapter.EXPECT().Set(s.ctx, key, gomock.Any(), time.Minute).Return(nil)
+
+	s.ErrorIs(s.l.CheckFor(s.ctx, "logins", "42", limiter.DurationSecond), limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestPenaltyRequiresBan() {
+	s.PanicsWithValue("limiter: penalty of logins must ban for a positive period, got 0s", func() {
+		limiter.WithPenalty("logins", limiter.Penalty{Violations: 2, Window: time.Minute})
+	})
+}
+
+func (s *LimiterSuite) TestPenaltyEscalationCapped() {
+	adapter := memory.NewAdapter()
+	s.newLimiter(adapter, penaltyLimits,
+		limiter.WithPenalty("logins", limiter.Penalty{Violations: 1, Window: time.Minute, Ban: time.Hour, Escalation: 1e6}))
+
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	var start, until time.Time
+	for i := 0; i < 3; i++ {
+		start = now
+		s.setNow(now)
+		s.Require().NoError(adapter.IncrBy(s.ctx, "logins:42:"+now.Add(-time.Second).Format("20060102150405"), 5))
+		s.Require().ErrorIs(s.l.CheckFor(s.ctx, "logins", "42", limiter.DurationSecond), limiter.ErrLimitExceeded)
+
+		res, err := s.l.Evaluate(s.ctx, "logins", "42", limiter.DurationSecond)
+		s.Require().NoError(err)
+		until = res.BannedUntil
+		now = until.Add(time.Second)
+	}
+
+	// the third ban would last 1e12 hours, overflowing a duration, it is capped to a year by default.
+	s.Equal(start.Add(365*24*time.Hour), until)
+}
@@ -0,0 +1,101 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Plan is a named bundle of limits across many metrics, e.g. "free", "pro" or "enterprise".
+type Plan struct {
+	Name string
+	// Limits is a map of metric name and evaluation duration with its limits.
+	Limits map[string]Limits
+}
+
+// PlanResolver resolves the plan a subject is assigned to.
+type PlanResolver interface {
+	// SubjectPlan returns the plan name of the subject and whether it is assigned to one.
+	SubjectPlan(ctx context.Context, subject string) (string, bool, error)
+}
+
+// Plans is a LimitProvider resolving the limits of a subject from its plan.
+// Subjects without a plan, and plans without the checked metric, keep the metric default limits.
+type Plans struct {
+	resolver PlanResolver
+	plans    map[string]Plan
+}
+
+// NewPlans returns a new Plans instance.
+// resolver assigns the subjects to one of plans.
+func NewPlans(resolver PlanResolver, plans ...Plan) *Plans {
+	p := &Plans{
+		resolver: resolver,
+		plans:    make(map[string]Plan, len(plans)),
+	}
+	for _, plan := range plans {
+		p.plans[plan.Name] = plan
+	}
+
+	return p
+}
+
+// SubjectLimits implements LimitProvider.
+func (p *Plans) SubjectLimits(ctx context.Context, metric, subject string) (Limits, bool, error) {
+	name, ok, err := p.resolver.SubjectPlan(ctx, subject)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	plan, ok := p.plans[name]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: %q", ErrPlanNotFound, name)
+	}
+
+	limits, ok := plan.Limits[metric]
+	return limits, ok, nil
+}
+
+// StaticPlanAssignments is a PlanResolver backed by a map of subject and plan name.
+type StaticPlanAssignments map[string]string
+
+// SubjectPlan implements PlanResolver.
+func (a StaticPlanAssignments) SubjectPlan(_ context.Context, subject string) (string, bool, error) {
+	plan, ok := a[subject]
+	return plan, ok, nil
+}
+
+// AdapterPlanAssignments is a PlanResolver storing the plan assignments in an Adapter,
+// so they are shared by every instance using the same storage.
+type AdapterPlanAssignments struct {
+	adapter Adapter
+}
+
+// NewAdapterPlanAssignments returns a new AdapterPlanAssignments instance.
+func NewAdapterPlanAssignments(adapter Adapter) *AdapterPlanAssignments {
+	return &AdapterPlanAssignments{adapter: adapter}
+}
+
+// SubjectPlan implements PlanResolver.
+func (a *AdapterPlanAssignments) SubjectPlan(ctx context.Context, subject string) (string, bool, error) {
+	var plan string
+	if err := a.adapter.Get(ctx, planKey(subject), &plan); err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+
+	return plan, true, nil
+}
+
+// Assign assigns the subject to the plan, expiration of zero keeps the assignment forever.
+func (a *AdapterPlanAssignments) Assign(ctx context.Context, subject, plan string, expiration time.Duration) error {
+	return a.adapter.Set(ctx, planKey(subject), plan, expiration)
+}
+
+func planKey(subject string) string {
+	return fmt.Sprintf("plan:%s", subject)
+}
@@ -0,0 +1,83 @@
+package limiter_test
+
+import (
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+var (
+	freePlan = limiter.Plan{
+		Name: "free",
+		Limits: map[string]limiter.Limits{
+			"metric_test": {limiter.DurationSecond: 1},
+		},
+	}
+	proPlan = limiter.Plan{
+		Name: "pro",
+		Limits: map[string]limiter.Limits{
+			"metric_test":  {limiter.DurationSecond: 100},
+			"metric_other": {limiter.DurationSecond: 100},
+		},
+	}
+)
+
+func (s *LimiterSuite) TestPlans() {
+	plans := limiter.NewPlans(limiter.StaticPlanAssignments{"user1": "free", "user2": "pro"}, freePlan, proPlan)
+	s.l = limiter.New(s.adapter, map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}, limiter.WithLimitProvider(plans, 0))
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(3), nil).Times(3)
+
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(int64(1), res.Limit)
+	s.Equal(limiter.StatusExceeded, res.Status)
+
+	res, err = s.l.Evaluate(s.ctx, "metric_test", "user2", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(int64(100), res.Limit)
+
+	// subjects without plan keep the metric default.
+	res, err = s.l.Evaluate(s.ctx, "metric_test", "user3", limiter.DurationSecond)
+	s.NoError(err)
+	s.Equal(int64(5), res.Limit)
+}
+
+func (s *LimiterSuite) TestPlansUnknownPlan() {
+	plans := limiter.NewPlans(limiter.StaticPlanAssignments{"user1": "gold"}, freePlan)
+
+	_, _, err := plans.SubjectLimits(s.ctx, "metric_test", "user1")
+	s.ErrorIs(err, limiter.ErrPlanNotFound)
+	s.ErrorContains(err, `"gold"`)
+}
+
+func (s *LimiterSuite) TestPlansMetricNotInPlan() {
+	plans := limiter.NewPlans(limiter.StaticPlanAssignments{"user1": "free"}, freePlan)
+
+	_, ok, err := plans.SubjectLimits(s.ctx, "metric_other", "user1")
+	s.NoError(err)
+	s.False(ok)
+}
+
+func (s *LimiterSuite) TestAdapterPlanAssignmentsUpgrade() {
+	adapter := memory.NewAdapter()
+	assignments := limiter.NewAdapterPlanAssignments(adapter)
+	l := limiter.New(adapter, map[string]limiter.Limits{
+		"metric_test":  {limiter.DurationSecond: 5},
+		"metric_other": {limiter.DurationSecond: 5},
+	}, limiter.WithLimitProvider(limiter.NewPlans(assignments, freePlan, proPlan), 0))
+
+	s.Require().NoError(assignments.Assign(s.ctx, "user1", "free", 0))
+	s.Require().NoError(adapter.IncrBy(s.ctx, "metric_test:user1:20240229231110", 10))
+	s.Require().NoError(adapter.IncrBy(s.ctx, "metric_other:user1:20240229231110", 10))
+	s.ErrorIs(l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond), limiter.ErrLimitExceeded)
+	s.ErrorIs(l.CheckFor(s.ctx, "metric_other", "user1", limiter.DurationSecond), limiter.ErrLimitExceeded)
+
+	s.Require().NoError(assignments.Assign(s.ctx, "user1", "pro", time.Hour))
+	s.NoError(l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond))
+	s.NoError(l.CheckFor(s.ctx, "metric_other", "user1", limiter.DurationSecond))
+}
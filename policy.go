@@ -0,0 +1,23 @@
+package limiter
+
+// FailPolicy decides the outcome of the records and checks of a metric when the adapter fails.
+type FailPolicy uint8
+
+const (
+	// FailClosed returns the adapter errors to the caller, it is the default policy.
+	FailClosed FailPolicy = iota
+	// FailOpen allows the checks and drops the records when the adapter fails.
+	FailOpen
+)
+
+// String returns the name of the policy.
+func (p FailPolicy) String() string {
+	switch p {
+	case FailClosed:
+		return "closed"
+	case FailOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
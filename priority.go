@@ -0,0 +1,47 @@
+package limiter
+
+import (
+	"context"
+	"math"
+)
+
+// Priority is the importance of the traffic checked with CheckPriority, the higher the more important.
+type Priority int
+
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+// CheckPriority checks if the metric has exceeded the share of its limits usable by the priority for the given subject.
+// The headroom above the share is left for the higher priorities, see WithPriorities.
+func (l *Limiter) CheckPriority(ctx context.Context, metric, subject string, duration Duration, priority Priority) error {
+	res, err := l.EvaluatePriority(ctx, metric, subject, duration, priority)
+	if err != nil {
+		return err
+	}
+
+	return res.err()
+}
+
+// EvaluatePriority checks the usage of the subject against the share of the metric limits usable by the priority.
+func (l *Limiter) EvaluatePriority(ctx context.Context, metric, subject string, duration Duration, priority Priority) (Result, error) {
+	return l.evaluate(ctx, metric, subject, duration, priority, true)
+}
+
+// priorityLimits returns the limits scaled down to the share of the priority.
+func (t *table) priorityLimits(metric string, priority Priority, limits Limits) Limits {
+	share, ok := t.priorities[metric][priority]
+	if !ok || share >= 1 {
+		return limits
+	}
+
+	scaled := make(Limits, len(limits))
+	for duration, limit := range limits {
+		scaled[duration] = int64(math.Floor(float64(limit) * math.Max(share, 0)))
+	}
+
+	return scaled
+}
@@ -0,0 +1,51 @@
+package limiter_test
+
+import (
+	"github.com/hendrywiranto/limiter"
+)
+
+var (
+	priorityLimits = map[string]limiter.Limits{"payments": {limiter.DurationSecond: 10}}
+	priorityOption = limiter.WithPriorities("payments", map[limiter.Priority]float64{
+		limiter.PriorityLow:    0.5,
+		limiter.PriorityNormal: 0.8,
+	})
+)
+
+func (s *LimiterSuite) TestPriorityReservedHeadroom() {
+	s.newLimiter(s.adapter, priorityLimits, priorityOption)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"payments:acme:20240229231110"}).Return(int64(6), nil).Times(3)
+
+	res, err := s.l.EvaluatePriority(s.ctx, "payments", "acme", limiter.DurationSecond, limiter.PriorityLow)
+	s.Require().NoError(err)
+	s.Equal(limiter.StatusExceeded, res.Status)
+	s.Equal(int64(5), res.Limit)
+
+	err = s.l.CheckPriority(s.ctx, "payments", "acme", limiter.DurationSecond, limiter.PriorityNormal)
+	s.NoError(err)
+	err = s.l.CheckPriority(s.ctx, "payments", "acme", limiter.DurationSecond, limiter.PriorityHigh)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestPriorityCriticalUsesFullLimits() {
+	s.newLimiter(s.adapter, priorityLimits, priorityOption)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"payments:acme:20240229231110"}).Return(int64(9), nil).Times(3)
+
+	err := s.l.CheckPriority(s.ctx, "payments", "acme", limiter.DurationSecond, limiter.PriorityNormal)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	err = s.l.CheckPriority(s.ctx, "payments", "acme", limiter.DurationSecond, limiter.PriorityCritical)
+	s.NoError(err)
+	err = s.l.CheckFor(s.ctx, "payments", "acme", limiter.DurationSecond)
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestPrioritySharesSurviveUpdates() {
+	s.newLimiter(s.adapter, priorityLimits, priorityOption)
+	s.l.SetLimit("payments", limiter.DurationSecond, 20)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"payments:acme:20240229231110"}).Return(int64(9), nil)
+
+	res, err := s.l.EvaluatePriority(s.ctx, "payments", "acme", limiter.DurationSecond, limiter.PriorityLow)
+	s.Require().NoError(err)
+	s.Equal(limiter.StatusOK, res.Status)
+	s.Equal(int64(10), res.Limit)
+}
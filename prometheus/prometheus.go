@@ -0,0 +1,79 @@
+// Package prometheus exposes the limiter decisions and adapter calls as Prometheus metrics.
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+// Collector is a prometheus.Collector and a limiter.Metrics.
+// Register it to a prometheus.Registerer and pass it to limiter.WithMetrics.
+type Collector struct {
+	decisions       *prometheus.CounterVec
+	adapterDuration *prometheus.HistogramVec
+	adapterErrors   *prometheus.CounterVec
+}
+
+var _ limiter.Metrics = (*Collector)(nil)
+
+// NewCollector returns a new Collector instance.
+// namespace is prepended to the metric names, it can be empty.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "limiter",
+			Name:      "decisions_total",
+			Help:      "Number of limit checks by metric, duration, decision and shadow mode.",
+		}, []string{"metric", "duration", "decision", "shadow"}),
+		adapterDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "limiter",
+			Name:      "adapter_duration_seconds",
+			Help:      "Latency of the adapter calls by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		adapterErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "limiter",
+			Name:      "adapter_errors_total",
+			Help:      "Number of failed adapter calls by operation.",
+		}, []string{"operation"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.decisions.Describe(ch)
+	c.adapterDuration.Describe(ch)
+	c.adapterErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.decisions.Collect(ch)
+	c.adapterDuration.Collect(ch)
+	c.adapterErrors.Collect(ch)
+}
+
+// ObserveDecision implements limiter.Metrics.
+func (c *Collector) ObserveDecision(metric string, duration limiter.Duration, allowed, shadow bool) {
+	decision := "allowed"
+	if !allowed {
+		decision = "denied"
+	}
+
+	c.decisions.WithLabelValues(metric, duration.String(), decision, strconv.FormatBool(shadow)).Inc()
+}
+
+// ObserveAdapterCall implements limiter.Metrics.
+func (c *Collector) ObserveAdapterCall(operation string, elapsed time.Duration, err error) {
+	c.adapterDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+	if err != nil {
+		c.adapterErrors.WithLabelValues(operation).Inc()
+	}
+}
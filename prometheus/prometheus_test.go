@@ -0,0 +1,120 @@
+package prometheus_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/mock"
+	"github.com/hendrywiranto/limiter/prometheus"
+)
+
+type PrometheusSuite struct {
+	suite.Suite
+
+	ctx       context.Context
+	adapter   *mock.MockAdapter
+	collector *prometheus.Collector
+	l         *limiter.Limiter
+}
+
+func TestPrometheus(t *testing.T) {
+	suite.Run(t, new(PrometheusSuite))
+}
+
+func (s *PrometheusSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.adapter = mock.NewMockAdapter(gomock.NewController(s.T()))
+	s.collector = prometheus.NewCollector("app")
+	limits := map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}
+	s.l = limiter.New(s.adapter, limits, limiter.WithMetrics(s.collector))
+
+	// mock the current time to 2024-02-29 23:11:11 UTC.
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	}
+}
+
+func (s *PrometheusSuite) TestDecisions() {
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(5), nil)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(6), nil)
+
+	s.Require().NoError(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond))
+	s.Require().ErrorIs(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond), limiter.ErrLimitExceeded)
+
+	expected := `
+# HELP app_limiter_decisions_total Number of limit checks by metric, duration, decision and shadow mode.
+# TYPE app_limiter_decisions_total counter
+app_limiter_decisions_total{decision="allowed",duration="second",metric="metric_test",shadow="false"} 1
+app_limiter_decisions_total{decision="denied",duration="second",metric="metric_test",shadow="false"} 1
+`
+	s.NoError(testutil.CollectAndCompare(s.collector, strings.NewReader(expected), "app_limiter_decisions_total"))
+}
+
+func (s *PrometheusSuite) TestShadowDecisions() {
+	limits := map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}
+	s.l = limiter.New(s.adapter, limits, limiter.WithMetrics(s.collector), limiter.WithShadow("metric_test"))
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(6), nil)
+
+	s.Require().NoError(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond))
+
+	expected := `
+# HELP app_limiter_decisions_total Number of limit checks by metric, duration, decision and shadow mode.
+# TYPE app_limiter_decisions_total counter
+app_limiter_decisions_total{decision="denied",duration="second",metric="metric_test",shadow="true"} 1
+`
+	s.NoError(testutil.CollectAndCompare(s.collector, strings.NewReader(expected), "app_limiter_decisions_total"))
+}
+
+func (s *PrometheusSuite) TestDenylistedDecisions() {
+	deny, err := limiter.NewSubjectList("203.0.113.0/24")
+	s.Require().NoError(err)
+	limits := map[string]limiter.Limits{
+		"metric_test": {limiter.DurationSecond: 5},
+	}
+	s.l = limiter.New(s.adapter, limits, limiter.WithMetrics(s.collector), limiter.WithDenylist(deny))
+
+	s.Require().ErrorIs(s.l.CheckFor(s.ctx, "metric_test", "203.0.113.7", limiter.DurationSecond), limiter.ErrLimitExceeded)
+
+	expected := `
+# HELP app_limiter_decisions_total Number of limit checks by metric, duration, decision and shadow mode.
+# TYPE app_limiter_decisions_total counter
+app_limiter_decisions_total{decision="denied",duration="second",metric="metric_test",shadow="false"} 1
+`
+	s.NoError(testutil.CollectAndCompare(s.collector, strings.NewReader(expected), "app_limiter_decisions_total"))
+}
+
+func (s *PrometheusSuite) TestAdapterCalls() {
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().IncrBy(s.ctx, gomock.Any(), int64(1)).Return(nil).Times(3)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), mockedErr)
+
+	s.Require().NoError(s.l.Record(s.ctx, "metric_test", 1))
+	s.Require().ErrorIs(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond), mockedErr)
+
+	expected := `
+# HELP app_limiter_adapter_errors_total Number of failed adapter calls by operation.
+# TYPE app_limiter_adapter_errors_total counter
+app_limiter_adapter_errors_total{operation="SumKeys"} 1
+`
+	s.NoError(testutil.CollectAndCompare(s.collector, strings.NewReader(expected), "app_limiter_adapter_errors_total"))
+	s.Equal(2, testutil.CollectAndCount(s.collector, "app_limiter_adapter_duration_seconds"))
+	s.Equal(0, testutil.CollectAndCount(s.collector, "app_limiter_decisions_total"))
+}
+
+func (s *PrometheusSuite) TestLint() {
+	problems, err := testutil.CollectAndLint(s.collector)
+	s.Require().NoError(err)
+	s.Empty(problems)
+}
@@ -0,0 +1,243 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueueOption configures a Queue.
+type QueueOption func(*Queue)
+
+// WithMaxQueueLength rejects the callers with ErrQueueFull once n callers are waiting, 0 means no limit.
+func WithMaxQueueLength(n int) QueueOption {
+	return func(q *Queue) {
+		q.maxLength = n
+	}
+}
+
+// WithQueueTimeout rejects the callers with ErrQueueTimeout once they have waited for d.
+func WithQueueTimeout(d time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.timeout = d
+	}
+}
+
+// WithWeights admits the callers in weighted-fair order rather than FIFO.
+// Every subject gets a share of the quota proportional to its weight, the subjects missing from weights weigh 1.
+func WithWeights(weights map[string]float64) QueueOption {
+	return func(q *Queue) {
+		q.weights = make(map[string]float64, len(weights))
+		for subject, weight := range weights {
+			q.weights[subject] = weight
+		}
+	}
+}
+
+// Queue admits the callers waiting for the quota of a metric one at a time, in FIFO or weighted-fair order,
+// so that no caller starves while the quota is exhausted.
+// It is local to the process, the callers of other instances are not queued.
+type Queue struct {
+	l       *Limiter
+	metric  string
+	subject string
+
+	maxLength int
+	timeout   time.Duration
+	weights   map[string]float64
+
+	mu      sync.Mutex
+	waiters []*waiter
+	// busy is set while the admitted caller waits on the limiter.
+	busy bool
+	// clock is the virtual time of the weighted-fair order, finish the last tag of every subject.
+	clock  float64
+	finish map[string]float64
+	seq    uint64
+}
+
+type waiter struct {
+	subject string
+	cost    int64
+	tag     float64
+	seq     uint64
+	ready   chan struct{}
+}
+
+// NewQueue returns a new Queue instance in front of the quota of the metric for the given subject.
+func NewQueue(l *Limiter, metric, subject string, opts ...QueueOption) *Queue {
+	q := &Queue{
+		l:       l,
+		metric:  metric,
+		subject: subject,
+		finish:  make(map[string]float64),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// Len returns the number of callers waiting in the queue.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.waiters)
+}
+
+// Wait blocks until the caller is admitted and the quota has room for cost, then records it like WaitFor.
+// subject identifies the caller for the weighted-fair order, the quota is the one of the queue.
+// The caller is rejected right away if the queue is full or its predicted wait exceeds the context deadline.
+func (q *Queue) Wait(ctx context.Context, subject string, cost int64) error {
+	if q.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, q.timeout, ErrQueueTimeout)
+		defer cancel()
+	}
+
+	q.mu.Lock()
+	if q.maxLength > 0 && len(q.waiters) >= q.maxLength {
+		q.mu.Unlock()
+		return ErrQueueFull
+	}
+
+	ahead := cost
+	for _, w := range q.waiters {
+		ahead += w.cost
+	}
+	w := q.enqueue(subject, cost)
+	q.mu.Unlock()
+
+	if err := q.predict(ctx, ahead); err != nil {
+		q.leave(w)
+		if ctx.Err() != nil {
+			return queueErr(ctx)
+		}
+
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		q.leave(w)
+		return queueErr(ctx)
+	case <-w.ready:
+	}
+
+	err := q.l.WaitFor(ctx, q.metric, q.subject, cost)
+	q.leave(w)
+	if ctx.Err() != nil {
+		return queueErr(ctx)
+	}
+
+	return err
+}
+
+// predict rejects the caller if the quota will not have room for the cost of the callers ahead and its own
+// before the context deadline.
+// The costs which can never fit at once in the quota are not predicted.
+func (q *Queue) predict(ctx context.Context, cost int64) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	at, _, err := q.l.available(ctx, q.metric, q.subject, cost)
+	if errors.Is(err, ErrLimitExceeded) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if at.Sub(Now()) > time.Until(deadline) {
+		return fmt.Errorf("%w: %s predicted wait exceeds the deadline", ErrLimitExceeded, q.metric)
+	}
+
+	return nil
+}
+
+// enqueue adds a waiter tagged with its place in the admission order and admits it if the queue is idle.
+// The caller must hold the lock.
+func (q *Queue) enqueue(subject string, cost int64) *waiter {
+	q.seq++
+	w := &waiter{subject: subject, cost: cost, seq: q.seq, ready: make(chan struct{})}
+	if q.weights != nil {
+		weight, ok := q.weights[subject]
+		if !ok || weight <= 0 {
+			weight = 1
+		}
+
+		start := q.clock
+		if q.finish[subject] > start {
+			start = q.finish[subject]
+		}
+		w.tag = start + float64(cost)/weight
+		q.finish[subject] = w.tag
+	}
+
+	q.waiters = append(q.waiters, w)
+	q.admit()
+
+	return w
+}
+
+// leave removes the waiter from the queue, or ends its turn if it was admitted, and admits the next one.
+func (q *Queue) leave(w *waiter) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		q.busy = false
+	default:
+		for i, queued := range q.waiters {
+			if queued == w {
+				q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if len(q.waiters) == 0 {
+		// the subjects restart even once the queue has drained.
+		q.clock, q.finish = 0, make(map[string]float64)
+	}
+	q.admit()
+}
+
+// admit lets the next waiter through if no admitted caller is waiting on the limiter.
+// The caller must hold the lock.
+func (q *Queue) admit() {
+	if q.busy || len(q.waiters) == 0 {
+		return
+	}
+
+	next := 0
+	for i, w := range q.waiters {
+		if w.tag < q.waiters[next].tag || (w.tag == q.waiters[next].tag && w.seq < q.waiters[next].seq) {
+			next = i
+		}
+	}
+
+	w := q.waiters[next]
+	q.waiters = append(q.waiters[:next], q.waiters[next+1:]...)
+	if w.tag > q.clock {
+		q.clock = w.tag
+	}
+	q.busy = true
+	close(w.ready)
+}
+
+// queueErr returns ErrQueueTimeout if the queue timeout is the reason the context is done.
+func queueErr(ctx context.Context) error {
+	if errors.Is(context.Cause(ctx), ErrQueueTimeout) {
+		return ErrQueueTimeout
+	}
+
+	return ctx.Err()
+}
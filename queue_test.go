@@ -0,0 +1,120 @@
+package limiter_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+// gateAdapter holds the reads until the gate is opened and logs the recorded costs in order.
+type gateAdapter struct {
+	*memory.Adapter
+	gate chan struct{}
+
+	mu    sync.Mutex
+	costs []int64
+}
+
+func (a *gateAdapter) SumKeyGroups(ctx context.Context, groups [][]string) ([]int64, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-a.gate:
+	}
+
+	return a.Adapter.SumKeyGroups(ctx, groups)
+}
+
+func (a *gateAdapter) IncrIf(ctx context.Context, conditions []limiter.Condition, increments []limiter.Increment) (int, int64, error) {
+	a.mu.Lock()
+	a.costs = append(a.costs, increments[0].Value)
+	a.mu.Unlock()
+
+	return a.Adapter.IncrIf(ctx, conditions, increments)
+}
+
+func (s *LimiterSuite) newQueue(opts ...limiter.QueueOption) (*limiter.Queue, *gateAdapter) {
+	adapter := &gateAdapter{Adapter: memory.NewAdapter(), gate: make(chan struct{})}
+	s.newLimiter(adapter, map[string]limiter.Limits{"jobs": {limiter.DurationMinute: 1000}})
+
+	return limiter.NewQueue(s.l, "jobs", "", opts...), adapter
+}
+
+// enqueue makes the callers wait in the queue one after the other, the first one being held by the gate.
+func (s *LimiterSuite) enqueue(q *limiter.Queue, subjects []string, costs []int64) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := range subjects {
+		wg.Add(1)
+		go func(subject string, cost int64) {
+			defer wg.Done()
+			s.NoError(q.Wait(s.ctx, subject, cost))
+		}(subjects[i], costs[i])
+
+		// the first caller is admitted right away and does not stay in the queue.
+		s.Eventually(func() bool { return q.Len() == i }, time.Second, time.Millisecond)
+	}
+
+	return &wg
+}
+
+func (s *LimiterSuite) TestQueueFIFO() {
+	q, adapter := s.newQueue()
+
+	wg := s.enqueue(q, []string{"a", "a", "a", "b"}, []int64{1, 2, 3, 4})
+	close(adapter.gate)
+	wg.Wait()
+
+	s.Equal([]int64{1, 2, 3, 4}, adapter.costs)
+}
+
+func (s *LimiterSuite) TestQueueWeightedFair() {
+	q, adapter := s.newQueue(limiter.WithWeights(map[string]float64{"b": 2}))
+
+	// b is served before the backlog of a.
+	wg := s.enqueue(q, []string{"a", "a", "a", "b"}, []int64{10, 11, 12, 13})
+	close(adapter.gate)
+	wg.Wait()
+
+	s.Equal([]int64{10, 13, 11, 12}, adapter.costs)
+}
+
+func (s *LimiterSuite) TestQueueFull() {
+	q, adapter := s.newQueue(limiter.WithMaxQueueLength(1))
+
+	wg := s.enqueue(q, []string{"a", "a"}, []int64{1, 2})
+	err := q.Wait(s.ctx, "b", 3)
+	s.ErrorIs(err, limiter.ErrQueueFull)
+
+	close(adapter.gate)
+	wg.Wait()
+}
+
+func (s *LimiterSuite) TestQueueTimeout() {
+	q, adapter := s.newQueue(limiter.WithQueueTimeout(50 * time.Millisecond))
+	defer close(adapter.gate)
+
+	err := q.Wait(s.ctx, "a", 1)
+	s.ErrorIs(err, limiter.ErrQueueTimeout)
+	s.Zero(q.Len())
+}
+
+func (s *LimiterSuite) TestQueuePredictedWait() {
+	adapter := memory.NewAdapter()
+	s.l = limiter.New(adapter, map[string]limiter.Limits{"jobs": {limiter.DurationMinute: 10}})
+	q := limiter.NewQueue(s.l, "jobs", "")
+	s.setNow(time.Date(2024, 0o2, 29, 23, 10, 30, 0, time.UTC))
+	s.Require().NoError(s.l.Record(s.ctx, "jobs", 10))
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC))
+
+	// the usage leaves the minute window at 23:11:31, in 20s.
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	err := q.Wait(ctx, "a", 1)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "predicted wait exceeds the deadline")
+	s.Zero(q.Len())
+}
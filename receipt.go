@@ -0,0 +1,38 @@
+package limiter
+
+import "context"
+
+// Receipt holds the increments of a record operation, so they can be given back with Refund.
+// It is a plain value which can be marshaled and refunded by another instance.
+type Receipt struct {
+	Increments []Increment
+}
+
+// Refund gives back the usage recorded by the receipt, subtracting it from the buckets it was written to
+// even if the clock has moved to a later second since.
+// Refunding a receipt twice subtracts its usage twice.
+// The sliding log metrics have no receipt to refund, RecordReceipt fails for them.
+func (l *Limiter) Refund(ctx context.Context, receipt Receipt) (err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Refund")
+	span.SetAttribute(AttributeKeyCount, len(receipt.Increments))
+	defer func() { endSpan(span, err) }()
+
+	// the keys refunded by the same value are decremented in a single call.
+	var values []int64
+	keys := make(map[int64][]string)
+	for _, increment := range receipt.Increments {
+		if _, ok := keys[increment.Value]; !ok {
+			values = append(values, increment.Value)
+		}
+		keys[increment.Value] = append(keys[increment.Value], increment.Key)
+	}
+
+	for _, value := range values {
+		if err := IncrByKeys(ctx, l.adapter, keys[value], -value); err != nil {
+			l.logAdapterError(ctx, OperationIncrByKeys, "", "", err)
+			return err
+		}
+	}
+
+	return nil
+}
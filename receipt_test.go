@@ -0,0 +1,71 @@
+package limiter_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+func (s *LimiterSuite) TestRefundAfterClockMoved() {
+	adapter := memory.NewAdapter()
+	s.l = limiter.New(adapter, map[string]limiter.Limits{"metric_test": {limiter.DurationMinute: 10}})
+
+	receipt, err := s.l.RecordReceipt(s.ctx, "metric_test", "42", 4)
+	s.Require().NoError(err)
+	s.Equal([]limiter.Increment{
+		{Key: "metric_test:42:20240229231111", Value: 4},
+		{Key: "metric_test:42:202402292311", Value: 4},
+		{Key: "metric_test:42:2024022923", Value: 4},
+	}, receipt.Increments)
+
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 12, 5, 0, time.UTC)
+	}
+	s.Require().NoError(s.l.Refund(s.ctx, receipt))
+
+	sums, err := adapter.SumKeyGroups(s.ctx, [][]string{
+		{"metric_test:42:20240229231111"}, {"metric_test:42:202402292311"}, {"metric_test:42:2024022923"},
+	})
+	s.Require().NoError(err)
+	s.Equal([]int64{0, 0, 0}, sums)
+}
+
+func (s *LimiterSuite) TestRefundTransaction() {
+	s.l = limiter.New(memory.NewAdapter(), transactionLimits)
+
+	receipt, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1}, limiter.Cost{Metric: "tokens", Value: 700})
+	s.Require().NoError(err)
+	s.Len(receipt.Increments, 6)
+
+	_, err = s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "tokens", Value: 700})
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+
+	s.Require().NoError(s.l.Refund(s.ctx, receipt))
+	_, err = s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "tokens", Value: 700})
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestRecordReceiptPartialFailure() {
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:20240229231111", int64(10)).Return(nil)
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:202402292311", int64(10)).Return(mockedErr)
+
+	receipt, err := s.l.RecordReceipt(s.ctx, "metric_test", "", 10)
+	s.ErrorIs(err, mockedErr)
+	s.Equal([]limiter.Increment{{Key: "metric_test:20240229231111", Value: 10}}, receipt.Increments)
+
+	s.adapter.EXPECT().IncrBy(s.ctx, "metric_test:20240229231111", int64(-10)).Return(nil)
+	s.NoError(s.l.Refund(s.ctx, receipt))
+}
+
+func (s *LimiterSuite) TestRefundError() {
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().IncrBy(s.ctx, gomock.Any(), int64(-1)).Return(mockedErr)
+
+	err := s.l.Refund(s.ctx, limiter.Receipt{Increments: []limiter.Increment{{Key: "metric_test:20240229231111", Value: 1}}})
+	s.ErrorIs(err, mockedErr)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hendrywiranto/limiter"
@@ -15,12 +18,128 @@ type redisClient interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
 	IncrBy(ctx context.Context, key string, value int64) *redis.IntCmd
 	MGet(ctx context.Context, keys ...string) *redis.SliceCmd
+	ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	redis.Scripter
 }
 
 type Adapter struct {
 	client redisClient
 }
 
+var (
+	_ limiter.Adapter            = (*Adapter)(nil)
+	_ limiter.BatchAdapter       = (*Adapter)(nil)
+	_ limiter.AtomicAdapter      = (*Adapter)(nil)
+	_ limiter.LeaseAdapter       = (*Adapter)(nil)
+	_ limiter.LeakyBucketAdapter = (*Adapter)(nil)
+	_ limiter.LogAdapter         = (*Adapter)(nil)
+	_ limiter.ExpiringAdapter    = (*Adapter)(nil)
+)
+
+// incrIfScript sums the keys of every condition and applies the increments only if they all hold.
+// KEYS are the keys of the conditions followed by the keys of the increments.
+// ARGV is the number of conditions, the key count, cost and limit of every condition, then the increment values.
+// It returns the 0-based index of the failing condition and its sum, or -1 when the increments were applied.
+var incrIfScript = redis.NewScript(`
+local k, a = 1, 2
+for i = 1, tonumber(ARGV[1]) do
+	local count, cost, limit = tonumber(ARGV[a]), tonumber(ARGV[a + 1]), tonumber(ARGV[a + 2])
+	a = a + 3
+
+	local sum = 0
+	for j = k, k + count - 1 do
+		local v = tonumber(redis.call("GET", KEYS[j]))
+		if v then
+			sum = sum + v
+		end
+	end
+	k = k + count
+
+	if sum + cost > limit then
+		return {i - 1, sum}
+	end
+end
+
+for j = k, #KEYS do
+	redis.call("INCRBY", KEYS[j], ARGV[a])
+	a = a + 1
+end
+
+return {-1, 0}
+`)
+
+// acquireLeaseScript adds the lease to the sorted set of the key, scored by its expiry in unix milliseconds,
+// if the set holds less than the limit of unexpired leases. The key expires with its last lease.
+// KEYS is the key, ARGV is the lease ID, the limit, now and the expiry.
+var acquireLeaseScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[3])
+if not redis.call("ZSCORE", KEYS[1], ARGV[1]) and redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[2]) then
+	return 0
+end
+
+redis.call("ZADD", KEYS[1], ARGV[4], ARGV[1])
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+redis.call("PEXPIREAT", KEYS[1], last[2])
+
+return 1
+`)
+
+// renewLeaseScript extends an unexpired lease of the sorted set of the key.
+// KEYS is the key, ARGV is the lease ID, now and the expiry.
+var renewLeaseScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[2])
+if not redis.call("ZSCORE", KEYS[1], ARGV[1]) then
+	return 0
+end
+
+redis.call("ZADD", KEYS[1], "XX", ARGV[3], ARGV[1])
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+redis.call("PEXPIREAT", KEYS[1], last[2])
+
+return 1
+`)
+
+// scheduleScript books the next slot of the leaky bucket of the key, storing the time it is drained in unix microseconds.
+// KEYS is the key, ARGV is now, the increment and the max delay in microseconds.
+// It returns whether the slot was booked and the time it starts.
+var scheduleScript = redis.NewScript(`
+local now, increment, max = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local at = math.max(tonumber(redis.call("GET", KEYS[1])) or 0, now)
+if at - now > max then
+	return {0, at}
+end
+
+redis.call("SET", KEYS[1], at + increment, "PX", math.max(1, math.ceil((at + increment - now) / 1000)))
+return {1, at}
+`)
+
+// appendLogScript adds an entry to the sorted set of the key, scored by its time in unix microseconds,
+// and evicts the entries older than the retention.
+// KEYS is the key, ARGV is the entry time and value, the eviction cutoff in unix microseconds and the retention in milliseconds.
+// The members are the entry time, its index among the entries of the same time and its value, so they are unique.
+var appendLogScript = redis.NewScript(`
+local n = redis.call("ZCOUNT", KEYS[1], ARGV[1], ARGV[1])
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[1] .. ":" .. n .. ":" .. ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+
+return n
+`)
+
+// incrByExpireScript increments the key and sets its expiry if it has none, i.e. when the increment created it.
+// KEYS is the key, ARGV is the value and the expiration in milliseconds.
+// It returns the new value of the key.
+var incrByExpireScript = redis.NewScript(`
+local v = redis.call("INCRBY", KEYS[1], ARGV[1])
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+
+return v
+`)
+
 func NewAdapter(client redisClient) *Adapter {
 	return &Adapter{client: client}
 }
@@ -38,7 +157,22 @@ func (a *Adapter) Get(ctx context.Context, key string, value interface{}) error
 	return json.Unmarshal(buff, value)
 }
 
-func (a *Adapter) Set(ctx context.Context, key string, value int64, exp time.Duration) error {
+// Set stores the integers as is, so they can be incremented by IncrBy, and the other values as JSON.
+func (a *Adapter) Set(ctx context.Context, key string, value interface{}, exp time.Duration) error {
+	switch v := value.(type) {
+	case int:
+		value = int64(v)
+	case int32:
+		value = int64(v)
+	case int64:
+	default:
+		buff, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		value = buff
+	}
+
 	return a.client.Set(ctx, key, value, exp).Err()
 }
 
@@ -47,18 +181,178 @@ func (a *Adapter) IncrBy(ctx context.Context, key string, value int64) error {
 	return err
 }
 
+// IncrByExpire increments the key by value and sets its expiry when created, in a single Lua script.
+func (a *Adapter) IncrByExpire(ctx context.Context, key string, value int64, expiration time.Duration) (int64, error) {
+	ttl := expiration.Milliseconds()
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	return incrByExpireScript.Run(ctx, a.client, []string{key}, value, ttl).Int64()
+}
+
 func (a *Adapter) SumKeys(ctx context.Context, keys []string) (int64, error) {
 	res, err := a.client.MGet(ctx, keys...).Result()
 	if err != nil {
 		return 0, err
 	}
 
+	return sumValues(res), nil
+}
+
+// IncrByKeys increments every key by value in a single pipeline.
+func (a *Adapter) IncrByKeys(ctx context.Context, keys []string, value int64) error {
+	_, err := a.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.IncrBy(ctx, key, value)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// SumKeyGroups returns the sum of every group of keys in a single pipeline.
+func (a *Adapter) SumKeyGroups(ctx context.Context, groups [][]string) ([]int64, error) {
+	cmds := make([]*redis.SliceCmd, len(groups))
+	_, err := a.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, keys := range groups {
+			cmds[i] = pipe.MGet(ctx, keys...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make([]int64, len(groups))
+	for i, cmd := range cmds {
+		sums[i] = sumValues(cmd.Val())
+	}
+
+	return sums, nil
+}
+
+// IncrIf applies the increments only if every condition holds, atomically in a single Lua script.
+// The script touches every key, so they must live on the same node of a Redis Cluster.
+func (a *Adapter) IncrIf(ctx context.Context, conditions []limiter.Condition, increments []limiter.Increment) (int, int64, error) {
+	keys := make([]string, 0, len(increments))
+	args := make([]interface{}, 0, 1+3*len(conditions)+len(increments))
+	args = append(args, len(conditions))
+	for _, condition := range conditions {
+		keys = append(keys, condition.Keys...)
+		args = append(args, len(condition.Keys), condition.Cost, condition.Limit)
+	}
+	for _, increment := range increments {
+		keys = append(keys, increment.Key)
+		args = append(args, increment.Value)
+	}
+
+	res, err := incrIfScript.Run(ctx, a.client, keys, args...).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(res) != 2 {
+		return 0, 0, fmt.Errorf("redis: unexpected IncrIf reply %v", res)
+	}
+
+	return int(res[0]), res[1], nil
+}
+
+// AcquireLease adds the lease to the sorted set of the key if it holds less than limit unexpired leases.
+func (a *Adapter) AcquireLease(ctx context.Context, key, id string, limit int64, now time.Time, ttl time.Duration) (bool, error) {
+	res, err := acquireLeaseScript.Run(ctx, a.client, []string{key}, id, limit, now.UnixMilli(), now.Add(ttl).UnixMilli()).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return res == 1, nil
+}
+
+// RenewLease extends an unexpired lease of the sorted set of the key.
+func (a *Adapter) RenewLease(ctx context.Context, key, id string, now time.Time, ttl time.Duration) (bool, error) {
+	res, err := renewLeaseScript.Run(ctx, a.client, []string{key}, id, now.UnixMilli(), now.Add(ttl).UnixMilli()).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return res == 1, nil
+}
+
+// ReleaseLease removes the lease from the sorted set of the key.
+func (a *Adapter) ReleaseLease(ctx context.Context, key, id string) error {
+	return a.client.ZRem(ctx, key, id).Err()
+}
+
+// Schedule books the next slot of the leaky bucket at key in a single Lua script.
+func (a *Adapter) Schedule(ctx context.Context, key string, now time.Time, increment, maxDelay time.Duration) (time.Time, bool, error) {
+	res, err := scheduleScript.Run(ctx, a.client, []string{key}, now.UnixMicro(), increment.Microseconds(), maxDelay.Microseconds()).Int64Slice()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if len(res) != 2 {
+		return time.Time{}, false, fmt.Errorf("redis: unexpected Schedule reply %v", res)
+	}
+
+	return time.UnixMicro(res[1]).In(now.Location()), res[0] == 1, nil
+}
+
+// AppendLog adds the entry to the sorted set of the key and evicts the entries older than retention in a single Lua script.
+func (a *Adapter) AppendLog(ctx context.Context, key string, entry limiter.LogEntry, retention time.Duration) error {
+	ttl := retention.Milliseconds()
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	return appendLogScript.Run(ctx, a.client, []string{key}, entry.At.UnixMicro(), entry.Value, entry.At.Add(-retention).UnixMicro(), ttl).Err()
+}
+
+// ReadLog returns the entries of the sorted set of the key logged after since and up to until, oldest first.
+func (a *Adapter) ReadLog(ctx context.Context, key string, since, until time.Time) ([]limiter.LogEntry, error) {
+	members, err := a.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: fmt.Sprintf("(%d", since.UnixMicro()),
+		Max: strconv.FormatInt(until.UnixMicro(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]limiter.LogEntry, 0, len(members))
+	for _, member := range members {
+		parts := strings.Split(member, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("redis: unexpected log entry %q", member)
+		}
+
+		at, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		value, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, limiter.LogEntry{At: time.UnixMicro(at).In(until.Location()), Value: value})
+	}
+
+	return entries, nil
+}
+
+// sumValues sums the integer values returned by MGET, the missing keys are skipped.
+func sumValues(values []interface{}) int64 {
 	var sum int64
-	for _, val := range res {
-		if numVal, ok := val.(int64); ok {
-			sum += numVal
+	for _, val := range values {
+		switch v := val.(type) {
+		case int64:
+			sum += v
+		case string:
+			if numVal, err := strconv.ParseInt(v, 10, 64); err == nil {
+				sum += numVal
+			}
 		}
 	}
 
-	return sum, nil
+	return sum
 }
@@ -3,11 +3,13 @@ package redis_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/go-redis/redismock/v9"
 	"github.com/golang/mock/gomock"
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/hendrywiranto/limiter"
@@ -87,6 +89,14 @@ func (s *RedisSuite) TestSetError() {
 	s.ErrorContains(err, "some error")
 }
 
+func (s *RedisSuite) TestSetJSON() {
+	s.redisMock.ExpectSet("mykey", []byte(`{"second":5}`), time.Hour).SetVal("OK")
+
+	err := s.adapter.Set(s.ctx, "mykey", limiter.Limits{limiter.DurationSecond: 5}, time.Hour)
+
+	s.Require().NoError(err)
+}
+
 // ==================== IncrBy Cases ====================
 
 func (s *RedisSuite) TestIncrBy() {
@@ -117,6 +127,15 @@ func (s *RedisSuite) TestSumKeys() {
 	s.Equal(int64(3), sum)
 }
 
+func (s *RedisSuite) TestSumKeysStrings() {
+	s.redisMock.ExpectMGet("key1", "key2", "key3").SetVal([]interface{}{"1", nil, "2"})
+
+	sum, err := s.adapter.SumKeys(s.ctx, []string{"key1", "key2", "key3"})
+
+	s.Require().NoError(err)
+	s.Equal(int64(3), sum)
+}
+
 func (s *RedisSuite) TestSumKeysError() {
 	s.redisMock.ExpectMGet("key1", "key2").SetErr(errors.New("some error"))
 
@@ -126,3 +145,225 @@ func (s *RedisSuite) TestSumKeysError() {
 	s.Require().ErrorContains(err, "some error")
 	s.Empty(sum)
 }
+
+// ==================== Batch Cases ====================
+
+func (s *RedisSuite) TestIncrByKeys() {
+	s.redisMock.ExpectIncrBy("key1", int64(2)).SetVal(2)
+	s.redisMock.ExpectIncrBy("key2", int64(2)).SetVal(4)
+
+	err := s.adapter.IncrByKeys(s.ctx, []string{"key1", "key2"}, 2)
+
+	s.Require().NoError(err)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestIncrByKeysError() {
+	s.redisMock.ExpectIncrBy("key1", int64(2)).SetErr(errors.New("some error"))
+
+	err := s.adapter.IncrByKeys(s.ctx, []string{"key1"}, 2)
+
+	s.Require().Error(err)
+	s.ErrorContains(err, "some error")
+}
+
+func (s *RedisSuite) TestSumKeyGroups() {
+	s.redisMock.ExpectMGet("key1", "key2").SetVal([]interface{}{"1", "2"})
+	s.redisMock.ExpectMGet("key3").SetVal([]interface{}{nil})
+
+	sums, err := s.adapter.SumKeyGroups(s.ctx, [][]string{{"key1", "key2"}, {"key3"}})
+
+	s.Require().NoError(err)
+	s.Equal([]int64{3, 0}, sums)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestSumKeyGroupsError() {
+	s.redisMock.ExpectMGet("key1").SetErr(errors.New("some error"))
+
+	sums, err := s.adapter.SumKeyGroups(s.ctx, [][]string{{"key1"}})
+
+	s.Require().Error(err)
+	s.ErrorContains(err, "some error")
+	s.Nil(sums)
+}
+
+// ==================== Atomic Cases ====================
+
+func (s *RedisSuite) TestIncrIf() {
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$",
+		[]string{"a1", "a2", "b1", "a3", "b3"},
+		2, 2, int64(3), int64(10), 1, int64(1), int64(5), int64(3), int64(1),
+	).SetVal([]interface{}{int64(-1), int64(0)})
+
+	failed, sum, err := s.adapter.IncrIf(s.ctx, []limiter.Condition{
+		{Keys: []string{"a1", "a2"}, Cost: 3, Limit: 10},
+		{Keys: []string{"b1"}, Cost: 1, Limit: 5},
+	}, []limiter.Increment{{Key: "a3", Value: 3}, {Key: "b3", Value: 1}})
+
+	s.Require().NoError(err)
+	s.Equal(-1, failed)
+	s.Empty(sum)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestIncrIfFailed() {
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$",
+		[]string{"a1", "a3"},
+		1, 1, int64(3), int64(10), int64(3),
+	).SetVal([]interface{}{int64(0), int64(8)})
+
+	failed, sum, err := s.adapter.IncrIf(s.ctx, []limiter.Condition{
+		{Keys: []string{"a1"}, Cost: 3, Limit: 10},
+	}, []limiter.Increment{{Key: "a3", Value: 3}})
+
+	s.Require().NoError(err)
+	s.Equal(0, failed)
+	s.Equal(int64(8), sum)
+}
+
+func (s *RedisSuite) TestIncrIfError() {
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"a1"}, 0, int64(1)).
+		SetErr(errors.New("some error"))
+
+	_, _, err := s.adapter.IncrIf(s.ctx, nil, []limiter.Increment{{Key: "a1", Value: 1}})
+
+	s.Require().Error(err)
+	s.ErrorContains(err, "some error")
+}
+
+// ==================== Lease Cases ====================
+
+func (s *RedisSuite) TestAcquireLease() {
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"lease"}, "a", int64(2), now.UnixMilli(), now.Add(time.Minute).UnixMilli()).
+		SetVal(int64(1))
+
+	ok, err := s.adapter.AcquireLease(s.ctx, "lease", "a", 2, now, time.Minute)
+
+	s.Require().NoError(err)
+	s.True(ok)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestRenewLease() {
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"lease"}, "a", now.UnixMilli(), now.Add(time.Minute).UnixMilli()).
+		SetVal(int64(0))
+
+	ok, err := s.adapter.RenewLease(s.ctx, "lease", "a", now, time.Minute)
+
+	s.Require().NoError(err)
+	s.False(ok)
+}
+
+func (s *RedisSuite) TestReleaseLease() {
+	s.redisMock.ExpectZRem("lease", "a").SetVal(1)
+
+	err := s.adapter.ReleaseLease(s.ctx, "lease", "a")
+
+	s.Require().NoError(err)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestAcquireLeaseError() {
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"lease"}, "a", int64(2), int64(0), int64(60000)).
+		SetErr(errors.New("some error"))
+
+	_, err := s.adapter.AcquireLease(s.ctx, "lease", "a", 2, time.UnixMilli(0), time.Minute)
+
+	s.Require().Error(err)
+	s.ErrorContains(err, "some error")
+}
+
+// ==================== Leaky Bucket Cases ====================
+
+func (s *RedisSuite) TestSchedule() {
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	at := now.Add(100 * time.Millisecond)
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"bucket"}, now.UnixMicro(), int64(100000), int64(300000)).
+		SetVal([]interface{}{int64(1), at.UnixMicro()})
+
+	res, ok, err := s.adapter.Schedule(s.ctx, "bucket", now, 100*time.Millisecond, 300*time.Millisecond)
+
+	s.Require().NoError(err)
+	s.True(ok)
+	s.Equal(at, res)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestScheduleError() {
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"bucket"}, int64(0), int64(1000), int64(0)).
+		SetErr(errors.New("some error"))
+
+	_, _, err := s.adapter.Schedule(s.ctx, "bucket", time.UnixMicro(0), time.Millisecond, 0)
+
+	s.Require().Error(err)
+	s.ErrorContains(err, "some error")
+}
+
+// ==================== Expiring Counter Cases ====================
+
+func (s *RedisSuite) TestIncrByExpire() {
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"counter"}, int64(1), int64(60000)).SetVal(int64(2))
+
+	current, err := s.adapter.IncrByExpire(s.ctx, "counter", 1, time.Minute)
+
+	s.Require().NoError(err)
+	s.Equal(int64(2), current)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestIncrByExpireError() {
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"counter"}, int64(1), int64(1)).SetErr(errors.New("some error"))
+
+	_, err := s.adapter.IncrByExpire(s.ctx, "counter", 1, 0)
+
+	s.Require().Error(err)
+	s.ErrorContains(err, "some error")
+}
+
+// ==================== Sliding Log Cases ====================
+
+func (s *RedisSuite) TestAppendLog() {
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	s.redisMock.Regexp().ExpectEvalSha("^[0-9a-f]{40}$", []string{"log"}, now.UnixMicro(), int64(3), now.Add(-time.Minute).UnixMicro(), int64(60000)).
+		SetVal(int64(0))
+
+	err := s.adapter.AppendLog(s.ctx, "log", limiter.LogEntry{At: now, Value: 3}, time.Minute)
+
+	s.Require().NoError(err)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestReadLog() {
+	now := time.Date(2024, 0o2, 29, 23, 11, 11, 0, time.UTC)
+	since := now.Add(-time.Minute)
+	s.redisMock.ExpectZRangeByScore("log", &goredis.ZRangeBy{
+		Min: fmt.Sprintf("(%d", since.UnixMicro()),
+		Max: fmt.Sprint(now.UnixMicro()),
+	}).SetVal([]string{
+		fmt.Sprintf("%d:0:3", since.Add(time.Second).UnixMicro()),
+		fmt.Sprintf("%d:0:2", now.UnixMicro()),
+		fmt.Sprintf("%d:1:1", now.UnixMicro()),
+	})
+
+	entries, err := s.adapter.ReadLog(s.ctx, "log", since, now)
+
+	s.Require().NoError(err)
+	s.Equal([]limiter.LogEntry{
+		{At: since.Add(time.Second), Value: 3},
+		{At: now, Value: 2},
+		{At: now, Value: 1},
+	}, entries)
+	s.NoError(s.redisMock.ExpectationsWereMet())
+}
+
+func (s *RedisSuite) TestReadLogUnexpectedEntry() {
+	s.redisMock.ExpectZRangeByScore("log", &goredis.ZRangeBy{Min: "(0", Max: "0"}).SetVal([]string{"member"})
+
+	_, err := s.adapter.ReadLog(s.ctx, "log", time.UnixMicro(0), time.UnixMicro(0))
+
+	s.Require().Error(err)
+	s.ErrorContains(err, "unexpected log entry")
+}
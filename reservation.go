@@ -0,0 +1,92 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// Reservation is capacity booked ahead by Reserve, usable from TimeToAct.
+type Reservation struct {
+	Metric    string
+	Subject   string
+	Cost      int64
+	TimeToAct time.Time
+	// Receipt holds the usage recorded for the reservation.
+	Receipt Receipt
+
+	l *Limiter
+}
+
+// Delay returns how long to wait before acting on the reservation.
+func (r *Reservation) Delay() time.Duration {
+	if delay := r.TimeToAct.Sub(Now()); delay > 0 {
+		return delay
+	}
+
+	return 0
+}
+
+// Cancel gives the reserved units back, so the later reservations can act sooner.
+// Like golang.org/x/time/rate, cancelling a reservation whose time to act has passed is a no-op.
+func (r *Reservation) Cancel(ctx context.Context) error {
+	if !Now().Before(r.TimeToAct) {
+		return nil
+	}
+
+	receipt := r.Receipt
+	r.Receipt = Receipt{}
+
+	return r.l.Refund(ctx, receipt)
+}
+
+// Reserve books cost units of the metric for the given subject ahead.
+// The units are recorded right away, like the tokens of golang.org/x/time/rate,
+// so the later reservations and checks are delayed until the windows have room for them again.
+// The caller must wait for the reservation delay before acting, or cancel it.
+// It returns ErrLimitExceeded if the cost can never fit.
+func (l *Limiter) Reserve(ctx context.Context, metric, subject string, cost int64) (_ *Reservation, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.Reserve")
+	span.SetAttribute(AttributeMetric, metric)
+	span.SetAttribute(AttributeSubject, subject)
+	span.SetAttribute(AttributeValue, cost)
+	defer func() { endSpan(span, err) }()
+
+	for {
+		at, conditions, err := l.available(ctx, metric, subject, cost)
+		if err != nil {
+			return nil, err
+		}
+
+		// the usage of the allowlisted subjects is not recorded.
+		if allowed, _ := l.table.Load().listed(subject); allowed {
+			return &Reservation{Metric: metric, Subject: subject, Cost: cost, TimeToAct: at, l: l}, nil
+		}
+
+		increments := make([]Increment, 0, 3)
+		for _, key := range recordKeys(metric, subject, Now()) {
+			increments = append(increments, Increment{Key: key, Value: cost})
+		}
+
+		// the record only goes through if the usage has not changed since the time to act was computed.
+		failed, _, err := IncrIf(ctx, l.adapter, conditions, increments)
+		if err != nil {
+			l.logAdapterError(ctx, OperationIncrIf, metric, subject, err)
+			return nil, err
+		}
+
+		if failed < 0 {
+			return &Reservation{
+				Metric:    metric,
+				Subject:   subject,
+				Cost:      cost,
+				TimeToAct: at,
+				Receipt:   Receipt{Increments: increments},
+				l:         l,
+			}, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
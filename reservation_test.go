@@ -0,0 +1,83 @@
+package limiter_test
+
+import (
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+	"github.com/hendrywiranto/limiter/mock"
+)
+
+var reservationLimits = map[string]limiter.Limits{"units": {limiter.DurationMinute: 10}}
+
+func (s *LimiterSuite) TestReserve() {
+	s.l = limiter.New(memory.NewAdapter(), reservationLimits)
+
+	first, err := s.l.Reserve(s.ctx, "units", "scheduler", 8)
+	s.Require().NoError(err)
+	s.Zero(first.Delay())
+
+	// the 8 units of the current second leave the minute window at 23:12:12.
+	second, err := s.l.Reserve(s.ctx, "units", "scheduler", 5)
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 12, 0, time.UTC), second.TimeToAct)
+	s.Equal(61*time.Second, second.Delay())
+
+	err = s.l.CheckFor(s.ctx, "units", "scheduler", limiter.DurationMinute)
+	s.NoError(err, "the current second is not checked yet")
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 12, 0, time.UTC)
+	}
+	err = s.l.CheckFor(s.ctx, "units", "scheduler", limiter.DurationMinute)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestReserveCancel() {
+	s.l = limiter.New(memory.NewAdapter(), reservationLimits)
+
+	first, err := s.l.Reserve(s.ctx, "units", "scheduler", 8)
+	s.Require().NoError(err)
+	second, err := s.l.Reserve(s.ctx, "units", "scheduler", 5)
+	s.Require().NoError(err)
+
+	// the first reservation may already act, cancelling it gives nothing back.
+	s.Require().NoError(first.Cancel(s.ctx))
+	s.Require().NoError(second.Cancel(s.ctx))
+
+	third, err := s.l.Reserve(s.ctx, "units", "scheduler", 2)
+	s.Require().NoError(err)
+	s.Zero(third.Delay())
+}
+
+func (s *LimiterSuite) TestReserveRetriesOnConcurrentRecord() {
+	atomic := mock.NewMockAtomicAdapter(s.ctrl)
+	s.l = limiter.New(atomicAdapter{MockAdapter: s.adapter, MockAtomicAdapter: atomic}, reservationLimits)
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), nil).AnyTimes()
+	gomock.InOrder(
+		atomic.EXPECT().IncrIf(s.ctx, gomock.Len(1), gomock.Len(3)).Return(0, int64(3), nil),
+		atomic.EXPECT().IncrIf(s.ctx, gomock.Len(1), gomock.Len(3)).Return(-1, int64(0), nil),
+	)
+
+	r, err := s.l.Reserve(s.ctx, "units", "scheduler", 5)
+	s.Require().NoError(err)
+	s.Equal(int64(5), r.Cost)
+}
+
+func (s *LimiterSuite) TestReserveNeverFits() {
+	s.l = limiter.New(memory.NewAdapter(), reservationLimits)
+
+	_, err := s.l.Reserve(s.ctx, "units", "scheduler", 11)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestReserveWithoutSubject() {
+	s.l = limiter.New(memory.NewAdapter(), reservationLimits)
+	s.Require().NoError(s.l.Record(s.ctx, "units", 8))
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 20, 0, time.UTC))
+
+	r, err := s.l.Reserve(s.ctx, "units", "", 5)
+	s.Require().NoError(err)
+	s.Equal(time.Date(2024, 0o2, 29, 23, 12, 12, 0, time.UTC), r.TimeToAct)
+}
@@ -0,0 +1,75 @@
+package limiter
+
+import "time"
+
+// Status is the outcome of a check.
+type Status uint8
+
+const (
+	StatusUnknown Status = iota
+	// StatusOK means the usage is within the limits.
+	StatusOK
+	// StatusWarning means the usage is over the soft limit but within the hard limit, the check is allowed.
+	StatusWarning
+	// StatusExceeded means the usage is over the hard limit, the check is denied.
+	StatusExceeded
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusWarning:
+		return "warning"
+	case StatusExceeded:
+		return "exceeded"
+	default:
+		return "unknown"
+	}
+}
+
+// Result is the detailed outcome of a check.
+type Result struct {
+	Status Status
+	// Usage is the usage of the subject over the checked duration.
+	Usage int64
+	// Limit is the hard limit of the checked duration.
+	Limit int64
+	// SoftLimit is the soft limit of the checked duration, zero when it is not set.
+	SoftLimit int64
+	// Shadow reports the metric is in shadow mode.
+	// Status is then what the check would have returned, but the check is always allowed.
+	Shadow bool
+	// Fallback reports the usage could not be read and the check was allowed by the FailOpen policy.
+	Fallback bool
+	// BannedUntil is the end of the ban of the subject, zero when it is not banned.
+	// The usage is not read while the subject is banned.
+	BannedUntil time.Time
+	// Allowlisted and Denylisted report the subject bypassed the limits or was denied outright,
+	// without reading its usage.
+	Allowlisted bool
+	Denylisted  bool
+}
+
+// Allowed reports whether the check is allowed, i.e. the usage is within the hard limit or the metric is in shadow mode.
+func (r Result) Allowed() bool {
+	return r.Shadow || r.Status == StatusOK || r.Status == StatusWarning
+}
+
+// err returns the error of a check with the result, ErrLimitExceeded if it is not allowed.
+func (r Result) err() error {
+	if r.Allowed() {
+		return nil
+	}
+
+	if r.Denylisted {
+		return errDenylisted()
+	}
+
+	if !r.BannedUntil.IsZero() {
+		return errBanned(r.BannedUntil)
+	}
+
+	return ErrLimitExceeded
+}
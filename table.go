@@ -0,0 +1,226 @@
+package limiter
+
+// table holds the limit configuration of every metric.
+// A published table is never modified, updates swap in a modified copy,
+// so in-flight checks keep evaluating against the table they started with.
+type table struct {
+	limits     map[string]Limits
+	softLimits map[string]Limits
+	shadow     map[string]bool
+	failPolicy map[string]FailPolicy
+	algorithms map[string]Algorithm
+
+	concurrency map[string]Concurrency
+	priorities  map[string]map[Priority]float64
+	penalties   map[string]Penalty
+	adaptive    map[string]*adaptiveLimit
+
+	leakyBuckets map[string]LeakyBucket
+
+	allowlist *SubjectList
+	denylist  *SubjectList
+}
+
+func newTable(limits map[string]Limits) *table {
+	return &table{
+		limits:     cloneLimitsMap(limits),
+		softLimits: make(map[string]Limits),
+		shadow:     make(map[string]bool),
+		failPolicy: make(map[string]FailPolicy),
+		algorithms: make(map[string]Algorithm),
+
+		concurrency: make(map[string]Concurrency),
+		priorities:  make(map[string]map[Priority]float64),
+		penalties:   make(map[string]Penalty),
+		adaptive:    make(map[string]*adaptiveLimit),
+
+		leakyBuckets: make(map[string]LeakyBucket),
+	}
+}
+
+func (t *table) clone() *table {
+	shadow := make(map[string]bool, len(t.shadow))
+	for metric, enabled := range t.shadow {
+		shadow[metric] = enabled
+	}
+
+	failPolicy := make(map[string]FailPolicy, len(t.failPolicy))
+	for metric, policy := range t.failPolicy {
+		failPolicy[metric] = policy
+	}
+
+	algorithms := make(map[string]Algorithm, len(t.algorithms))
+	for metric, a := range t.algorithms {
+		algorithms[metric] = a
+	}
+
+	concurrency := make(map[string]Concurrency, len(t.concurrency))
+	for metric, c := range t.concurrency {
+		concurrency[metric] = c
+	}
+
+	// the shares of a metric are never modified once set, they can be shared between the tables.
+	priorities := make(map[string]map[Priority]float64, len(t.priorities))
+	for metric, shares := range t.priorities {
+		priorities[metric] = shares
+	}
+
+	penalties := make(map[string]Penalty, len(t.penalties))
+	for metric, p := range t.penalties {
+		penalties[metric] = p
+	}
+
+	// the adaptive limits keep their state across the tables until reloaded.
+	adaptive := make(map[string]*adaptiveLimit, len(t.adaptive))
+	for metric, a := range t.adaptive {
+		adaptive[metric] = a
+	}
+
+	leakyBuckets := make(map[string]LeakyBucket, len(t.leakyBuckets))
+	for metric, b := range t.leakyBuckets {
+		leakyBuckets[metric] = b
+	}
+
+	return &table{
+		limits:     cloneLimitsMap(t.limits),
+		softLimits: cloneLimitsMap(t.softLimits),
+		shadow:     shadow,
+		failPolicy: failPolicy,
+		algorithms: algorithms,
+
+		concurrency: concurrency,
+		priorities:  priorities,
+		penalties:   penalties,
+		adaptive:    adaptive,
+
+		leakyBuckets: leakyBuckets,
+
+		allowlist: t.allowlist,
+		denylist:  t.denylist,
+	}
+}
+
+// update applies fn to a copy of the current table and publishes it.
+func (l *Limiter) update(fn func(t *table)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t := l.table.Load().clone()
+	fn(t)
+	l.table.Store(t)
+}
+
+// UpdateLimits replaces the limits of every metric.
+// Metrics missing from limits are removed, it is safe to call while checks are in flight.
+func (l *Limiter) UpdateLimits(limits map[string]Limits) {
+	l.update(func(t *table) {
+		t.limits = cloneLimitsMap(limits)
+	})
+}
+
+// MetricConfig is the configuration of a metric replaced as a whole by Configure.
+type MetricConfig struct {
+	Limits     Limits
+	SoftLimits Limits
+	Shadow     bool
+	FailPolicy FailPolicy
+	Algorithm  Algorithm
+}
+
+// Configure replaces the limits, soft limits, shadow mode, fail policy and algorithm of every metric at once.
+// Metrics missing from metrics are removed from them and lose their adaptive limit,
+// the adaptive limits of the others are reloaded with their new limit, and their other settings are kept.
+// It is safe to call while checks are in flight.
+func (l *Limiter) Configure(metrics map[string]MetricConfig) {
+	l.update(func(t *table) {
+		t.limits = make(map[string]Limits, len(metrics))
+		t.softLimits = make(map[string]Limits)
+		t.shadow = make(map[string]bool)
+		t.failPolicy = make(map[string]FailPolicy)
+		t.algorithms = make(map[string]Algorithm)
+		for metric, c := range metrics {
+			t.limits[metric] = c.Limits.clone()
+			if len(c.SoftLimits) > 0 {
+				t.softLimits[metric] = c.SoftLimits.clone()
+			}
+			if c.Shadow {
+				t.shadow[metric] = true
+			}
+			if c.FailPolicy != FailClosed {
+				t.failPolicy[metric] = c.FailPolicy
+			}
+			if c.Algorithm != AlgorithmSlidingWindow {
+				t.algorithms[metric] = c.Algorithm
+			}
+		}
+		for metric, a := range t.adaptive {
+			c, ok := metrics[metric]
+			if !ok {
+				delete(t.adaptive, metric)
+				continue
+			}
+			t.adaptive[metric] = a.reload(c.Limits[a.Duration])
+		}
+	})
+}
+
+// SetLimit sets the limit of the metric for the given duration, adding the metric if needed.
+func (l *Limiter) SetLimit(metric string, duration Duration, limit int64) {
+	l.update(func(t *table) {
+		if t.limits[metric] == nil {
+			t.limits[metric] = make(Limits)
+		}
+		t.limits[metric][duration] = limit
+	})
+}
+
+// RemoveMetric removes the metric, its later records and checks fail with ErrMetricNotFound.
+func (l *Limiter) RemoveMetric(metric string) {
+	l.update(func(t *table) {
+		delete(t.limits, metric)
+		delete(t.softLimits, metric)
+		delete(t.shadow, metric)
+		delete(t.failPolicy, metric)
+		delete(t.algorithms, metric)
+		delete(t.concurrency, metric)
+		delete(t.priorities, metric)
+		delete(t.penalties, metric)
+		delete(t.adaptive, metric)
+		delete(t.leakyBuckets, metric)
+	})
+}
+
+// SetConcurrency sets the concurrency limit of the metric, adding the metric if needed.
+func (l *Limiter) SetConcurrency(metric string, c Concurrency) {
+	l.update(func(t *table) {
+		t.concurrency[metric] = c
+	})
+}
+
+// Limits returns a copy of the limits configured for the metric.
+func (l *Limiter) Limits(metric string) (Limits, bool) {
+	limits, ok := l.table.Load().limits[metric]
+	return limits.clone(), ok
+}
+
+func (l Limits) clone() Limits {
+	if l == nil {
+		return nil
+	}
+
+	cloned := make(Limits, len(l))
+	for duration, limit := range l {
+		cloned[duration] = limit
+	}
+
+	return cloned
+}
+
+func cloneLimitsMap(limits map[string]Limits) map[string]Limits {
+	cloned := make(map[string]Limits, len(limits))
+	for metric, l := range limits {
+		cloned[metric] = l.clone()
+	}
+
+	return cloned
+}
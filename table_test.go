@@ -0,0 +1,96 @@
+package limiter_test
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+)
+
+func (s *LimiterSuite) TestUpdateLimits() {
+	s.l.UpdateLimits(map[string]limiter.Limits{
+		"metric_new": {limiter.DurationSecond: 1},
+	})
+
+	_, ok := s.l.Limits("metric_test")
+	s.False(ok)
+	s.ErrorIs(s.l.Record(s.ctx, "metric_test", 1), limiter.ErrMetricNotFound)
+
+	limits, ok := s.l.Limits("metric_new")
+	s.True(ok)
+	s.Equal(limiter.Limits{limiter.DurationSecond: 1}, limits)
+}
+
+func (s *LimiterSuite) TestConfigure() {
+	s.l = limiter.New(s.adapter, nil, limiter.WithShadow("metric_test"))
+	s.l.Configure(map[string]limiter.MetricConfig{
+		"metric_test": {
+			Limits:     limiter.Limits{limiter.DurationSecond: 5},
+			SoftLimits: limiter.Limits{limiter.DurationSecond: 1},
+			FailPolicy: limiter.FailOpen,
+		},
+	})
+
+	// the shadow mode missing from the configuration is dropped.
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(2), nil)
+	res, err := s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.Equal(limiter.Result{Status: limiter.StatusWarning, Usage: 2, Limit: 5, SoftLimit: 1}, res)
+
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), errors.New("mocked error"))
+	res, err = s.l.Evaluate(s.ctx, "metric_test", "user1", limiter.DurationSecond)
+	s.Require().NoError(err)
+	s.True(res.Fallback)
+}
+
+func (s *LimiterSuite) TestSetLimit() {
+	s.l.SetLimit("metric_test", limiter.DurationSecond, 1)
+	s.l.SetLimit("metric_new", limiter.DurationMinute, 2)
+
+	limits, _ := s.l.Limits("metric_test")
+	s.Equal(int64(1), limits[limiter.DurationSecond])
+	s.Equal(int64(300), limits[limiter.DurationDay])
+
+	limits, ok := s.l.Limits("metric_new")
+	s.True(ok)
+	s.Equal(limiter.Limits{limiter.DurationMinute: 2}, limits)
+
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"metric_test:user1:20240229231110"}).Return(int64(2), nil)
+	s.ErrorIs(s.l.CheckFor(s.ctx, "metric_test", "user1", limiter.DurationSecond), limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestRemoveMetric() {
+	s.l.RemoveMetric("metric_test")
+
+	_, ok := s.l.Limits("metric_test")
+	s.False(ok)
+	s.ErrorIs(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond), limiter.ErrMetricNotFound)
+}
+
+func (s *LimiterSuite) TestLimitsIsCopy() {
+	limits, _ := s.l.Limits("metric_test")
+	limits[limiter.DurationSecond] = 100
+
+	limits, _ = s.l.Limits("metric_test")
+	s.Equal(int64(5), limits[limiter.DurationSecond])
+}
+
+func (s *LimiterSuite) TestUpdateLimitsConcurrently() {
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), nil).AnyTimes()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			s.l.SetLimit("metric_test", limiter.DurationSecond, int64(i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			s.NoError(s.l.Check(s.ctx, "metric_test", limiter.DurationSecond))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,75 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+)
+
+// Span attribute keys set by the limiter.
+const (
+	AttributeMetric   = "limiter.metric"
+	AttributeSubject  = "limiter.subject"
+	AttributeDuration = "limiter.duration"
+	AttributeValue    = "limiter.value"
+	AttributeKeyCount = "limiter.key_count"
+	AttributeDecision = "limiter.decision"
+	AttributeShadow   = "limiter.shadow"
+	AttributePriority = "limiter.priority"
+)
+
+// Decision values of the AttributeDecision span attribute.
+const (
+	DecisionAllowed = "allowed"
+	DecisionWarning = "warning"
+	DecisionDenied  = "denied"
+)
+
+// Tracer starts the spans of the limiter operations.
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced limiter operation.
+type Span interface {
+	// SetAttribute sets an attribute, value is either a string, an int, an int64 or a bool.
+	SetAttribute(key string, value interface{})
+	// End ends the span, err is the error the operation failed with, if any.
+	End(err error)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) End(error) {}
+
+// startSpan starts a span when a Tracer is configured.
+func (l *Limiter) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if l.tracer == nil {
+		return ctx, noopSpan{}
+	}
+
+	return l.tracer.StartSpan(ctx, name)
+}
+
+// decision returns the AttributeDecision value of the status.
+func decision(status Status) string {
+	switch status {
+	case StatusWarning:
+		return DecisionWarning
+	case StatusExceeded:
+		return DecisionDenied
+	default:
+		return DecisionAllowed
+	}
+}
+
+// endSpan ends the span, a denial is reported through AttributeDecision rather than as a failure.
+func endSpan(span Span, err error) {
+	if errors.Is(err, ErrLimitExceeded) {
+		err = nil
+	}
+
+	span.End(err)
+}
@@ -0,0 +1,139 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExceededError is the error of a transaction denied by a window of a metric, it wraps ErrLimitExceeded.
+type ExceededError struct {
+	Metric   string
+	Duration Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrLimitExceeded, e.Metric)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Cost is the value a transaction records to a metric.
+type Cost struct {
+	Metric string
+	Value  int64
+}
+
+// RecordAll checks and records several metrics for the subject as a single transaction.
+// Either every cost is recorded, or none is when any of them would take its metric over a limit,
+// in which case the returned ExceededError names the metric and the window denying it.
+// Unlike Check, the usage of the current second is counted so concurrent transactions see each other.
+// The transaction fails open on adapter errors only if every metric does.
+// The returned receipt gives the recorded costs back with Refund.
+//
+// The transaction is atomic across instances with adapters implementing AtomicAdapter,
+// otherwise it is only atomic within this process.
+func (l *Limiter) RecordAll(ctx context.Context, subject string, costs ...Cost) (receipt Receipt, err error) {
+	ctx, span := l.startSpan(ctx, "limiter.RecordAll")
+	span.SetAttribute(AttributeSubject, subject)
+	defer func() { endSpan(span, err) }()
+
+	type check struct {
+		metric   string
+		duration Duration
+		limits   Limits
+	}
+
+	t := l.table.Load()
+	now := Now()
+	var (
+		checks      []check
+		conditions  []Condition
+		increments  []Increment
+		metricCosts = make(map[string]int64, len(costs))
+	)
+	for _, cost := range costs {
+		if _, ok := t.limits[cost.Metric]; !ok {
+			return Receipt{}, fmt.Errorf("%w: %s", ErrMetricNotFound, cost.Metric)
+		}
+		if a := t.algorithms[cost.Metric]; a != AlgorithmSlidingWindow {
+			return Receipt{}, errUnsupportedAlgorithm(cost.Metric, a)
+		}
+		metricCosts[cost.Metric] += cost.Value
+	}
+
+	switch allowed, denied := t.listed(subject); {
+	case denied:
+		return Receipt{}, errDenylisted()
+	case allowed:
+		return Receipt{}, nil
+	}
+
+	for _, cost := range costs {
+		for _, key := range recordKeys(cost.Metric, subject, now) {
+			increments = append(increments, Increment{Key: key, Value: cost.Value})
+		}
+	}
+
+	// the costs of a metric listed several times are checked once, summed.
+	checked := make(map[string]bool, len(costs))
+	for _, cost := range costs {
+		if checked[cost.Metric] || t.shadow[cost.Metric] {
+			continue
+		}
+		checked[cost.Metric] = true
+		cost.Value = metricCosts[cost.Metric]
+		if until := l.bannedUntil(ctx, t, cost.Metric, subject); !until.IsZero() {
+			return Receipt{}, errBanned(until)
+		}
+
+		limits := l.subjectLimits(ctx, t, cost.Metric, subject)
+		current := fmt.Sprintf("%s:%s", keyPrefix(cost.Metric, subject), now.Format(secondFormat))
+		for _, duration := range Durations {
+			limit, ok := limits[duration]
+			if !ok {
+				continue
+			}
+
+			checks = append(checks, check{metric: cost.Metric, duration: duration, limits: limits})
+			conditions = append(conditions, Condition{
+				Keys:  append(l.windowKeys(cost.Metric, subject, duration), current),
+				Cost:  cost.Value,
+				Limit: limit,
+			})
+		}
+	}
+	span.SetAttribute(AttributeKeyCount, len(increments))
+
+	failed, sum, err := IncrIf(ctx, l.adapter, conditions, increments)
+	if err != nil {
+		failOpen := true
+		for _, cost := range costs {
+			l.logAdapterError(ctx, OperationIncrIf, cost.Metric, subject, err)
+			failOpen = failOpen && t.failPolicy[cost.Metric] == FailOpen
+		}
+		if failOpen {
+			for _, cost := range costs {
+				l.logFallback(ctx, OperationIncrIf, cost.Metric, subject)
+			}
+			return Receipt{}, nil
+		}
+
+		return Receipt{}, err
+	}
+
+	if failed >= 0 {
+		c := checks[failed]
+		span.SetAttribute(AttributeMetric, c.metric)
+		span.SetAttribute(AttributeDecision, DecisionDenied)
+		if _, err := l.decide(ctx, t, c.metric, subject, c.duration, c.limits, sum+conditions[failed].Cost); err != nil {
+			return Receipt{}, err
+		}
+
+		return Receipt{}, &ExceededError{Metric: c.metric, Duration: c.duration}
+	}
+	span.SetAttribute(AttributeDecision, DecisionAllowed)
+
+	return Receipt{Increments: increments}, nil
+}
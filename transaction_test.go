@@ -0,0 +1,123 @@
+package limiter_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+	"github.com/hendrywiranto/limiter/mock"
+)
+
+type atomicAdapter struct {
+	*mock.MockAdapter
+	*mock.MockAtomicAdapter
+}
+
+var transactionLimits = map[string]limiter.Limits{
+	"requests": {limiter.DurationSecond: 10},
+	"tokens":   {limiter.DurationSecond: 1000, limiter.DurationMinute: 5000},
+}
+
+func (s *LimiterSuite) TestTransactionSingleCall() {
+	atomic := mock.NewMockAtomicAdapter(s.ctrl)
+	s.l = limiter.New(atomicAdapter{MockAdapter: s.adapter, MockAtomicAdapter: atomic}, transactionLimits)
+	atomic.EXPECT().IncrIf(s.ctx, gomock.Len(3), []limiter.Increment{
+		{Key: "requests:42:20240229231111", Value: 1},
+		{Key: "requests:42:202402292311", Value: 1},
+		{Key: "requests:42:2024022923", Value: 1},
+		{Key: "tokens:42:20240229231111", Value: 700},
+		{Key: "tokens:42:202402292311", Value: 700},
+		{Key: "tokens:42:2024022923", Value: 700},
+	}).DoAndReturn(func(_, conditions, _ interface{}) (int, int64, error) {
+		c := conditions.([]limiter.Condition)
+		s.Equal([]string{"requests:42:20240229231110", "requests:42:20240229231111"}, c[0].Keys)
+		s.Equal(limiter.Condition{Keys: c[1].Keys, Cost: 700, Limit: 1000}, c[1])
+		s.Len(c[2].Keys, 61)
+		return -1, 0, nil
+	})
+
+	_, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1}, limiter.Cost{Metric: "tokens", Value: 700})
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestTransactionAllOrNothing() {
+	adapter := memory.NewAdapter()
+	s.l = limiter.New(adapter, transactionLimits)
+
+	_, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1}, limiter.Cost{Metric: "tokens", Value: 700})
+	s.NoError(err)
+
+	_, err = s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1}, limiter.Cost{Metric: "tokens", Value: 700})
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "tokens")
+
+	sum, err := adapter.SumKeys(s.ctx, []string{"requests:42:20240229231111"})
+	s.NoError(err)
+	s.Equal(int64(1), sum)
+
+	_, err = s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1}, limiter.Cost{Metric: "tokens", Value: 300})
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestTransactionRepeatedMetric() {
+	s.l = limiter.New(memory.NewAdapter(), transactionLimits)
+
+	_, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 6}, limiter.Cost{Metric: "requests", Value: 5})
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestTransactionFallback() {
+	s.l = limiter.New(s.adapter, transactionLimits)
+	s.adapter.EXPECT().SumKeys(s.ctx, []string{"requests:42:20240229231110", "requests:42:20240229231111"}).Return(int64(10), nil)
+
+	_, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1})
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+}
+
+func (s *LimiterSuite) TestTransactionShadow() {
+	s.l = limiter.New(s.adapter, transactionLimits, limiter.WithShadow("requests"))
+	s.adapter.EXPECT().IncrBy(s.ctx, gomock.Any(), int64(20)).Return(nil).Times(3)
+
+	_, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 20})
+	s.NoError(err)
+}
+
+func (s *LimiterSuite) TestTransactionErrors() {
+	s.l = limiter.New(s.adapter, transactionLimits, limiter.WithFailPolicy("requests", limiter.FailOpen))
+
+	_, err := s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "unknown", Value: 1})
+	s.ErrorIs(err, limiter.ErrMetricNotFound)
+
+	mockedErr := errors.New("mocked error")
+	s.adapter.EXPECT().SumKeys(s.ctx, gomock.Any()).Return(int64(0), mockedErr).Times(2)
+
+	_, err = s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1})
+	s.NoError(err)
+
+	_, err = s.l.RecordAll(s.ctx, "42", limiter.Cost{Metric: "requests", Value: 1}, limiter.Cost{Metric: "tokens", Value: 1})
+	s.ErrorIs(err, mockedErr)
+}
+
+func (s *LimiterSuite) TestTransactionWithoutSubject() {
+	s.l = limiter.New(memory.NewAdapter(), map[string]limiter.Limits{"m": {limiter.DurationMinute: 3}})
+	start := limiter.Now()
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		s.setNow(start.Add(time.Duration(i) * time.Second))
+		if _, err := s.l.RecordAll(s.ctx, "", limiter.Cost{Metric: "m", Value: 1}); err == nil {
+			allowed++
+		}
+	}
+
+	// the usage of the previous seconds is counted, not only the current one.
+	s.Equal(3, allowed)
+
+	_, err := s.l.RecordAll(s.ctx, "", limiter.Cost{Metric: "m", Value: 1})
+	var exceeded *limiter.ExceededError
+	s.Require().ErrorAs(err, &exceeded)
+	s.Equal(limiter.ExceededError{Metric: "m", Duration: limiter.DurationMinute}, *exceeded)
+}
@@ -0,0 +1,194 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Wait blocks until the metric has room for cost, then records it.
+func (l *Limiter) Wait(ctx context.Context, metric string, cost int64) error {
+	return l.WaitFor(ctx, metric, "", cost)
+}
+
+// WaitFor blocks until the metric has room for cost for the given subject, then records it.
+// The earliest time every window has room is computed from the usage of its buckets,
+// at the bucket granularity, so it may wait up to a bucket longer than strictly needed.
+// It returns the context error if the context is done first,
+// and ErrLimitExceeded right away if the cost can never fit or the context deadline is too early.
+func (l *Limiter) WaitFor(ctx context.Context, metric, subject string, cost int64) error {
+	for {
+		at, _, err := l.available(ctx, metric, subject, cost)
+		if err != nil {
+			return err
+		}
+
+		if delay := at.Sub(Now()); delay > 0 {
+			if deadline, ok := ctx.Deadline(); ok && deadline.Before(time.Now().Add(delay)) {
+				return fmt.Errorf("%w: %s available after the context deadline", ErrLimitExceeded, metric)
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		// another caller may have taken the room in the meantime, the record is denied then.
+		_, err = l.RecordAll(ctx, subject, Cost{Metric: metric, Value: cost})
+		if !errors.Is(err, ErrLimitExceeded) {
+			return err
+		}
+
+		// wait for the next second at least, for the next buckets to be counted.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(Now().Truncate(time.Second).Add(time.Second).Sub(Now())):
+		}
+	}
+}
+
+// bucket is the usage recorded over a period starting at start.
+type bucket struct {
+	start  time.Time
+	length time.Duration
+	usage  int64
+}
+
+// available returns the earliest time every window of the metric has room for cost for the subject,
+// and the conditions holding as long as the usage it was computed from has not changed.
+func (l *Limiter) available(ctx context.Context, metric, subject string, cost int64) (time.Time, []Condition, error) {
+	t := l.table.Load()
+	if _, ok := t.limits[metric]; !ok {
+		return time.Time{}, nil, ErrMetricNotFound
+	}
+	if a := t.algorithms[metric]; a != AlgorithmSlidingWindow {
+		return time.Time{}, nil, errUnsupportedAlgorithm(metric, a)
+	}
+
+	now := Now()
+	switch allowed, denied := t.listed(subject); {
+	case denied:
+		return time.Time{}, nil, errDenylisted()
+	case allowed:
+		return now, nil, nil
+	}
+
+	if t.shadow[metric] {
+		return now, nil, nil
+	}
+	if until := l.bannedUntil(ctx, t, metric, subject); !until.IsZero() {
+		return time.Time{}, nil, errBanned(until)
+	}
+
+	limits := l.subjectLimits(ctx, t, metric, subject)
+	current := fmt.Sprintf("%s:%s", keyPrefix(metric, subject), now.Format(secondFormat))
+	windows := make(map[Duration][]string)
+	var groups [][]string
+	for _, duration := range Durations {
+		limit, ok := limits[duration]
+		if !ok {
+			continue
+		}
+		if cost > limit {
+			return time.Time{}, nil, fmt.Errorf("%w: %s cost %d is over the %s limit %d", ErrLimitExceeded, metric, cost, duration, limit)
+		}
+
+		// the current second is not checked yet, but will be in the later windows.
+		windows[duration] = append(l.windowKeys(metric, subject, duration), current)
+		for _, key := range windows[duration] {
+			groups = append(groups, []string{key})
+		}
+	}
+
+	usages, err := SumKeyGroups(ctx, l.adapter, groups)
+	if err != nil {
+		l.logAdapterError(ctx, OperationSumKeyGroups, metric, subject, err)
+		return time.Time{}, nil, err
+	}
+
+	at, i := now, 0
+	var conditions []Condition
+	for _, duration := range Durations {
+		keys, ok := windows[duration]
+		if !ok {
+			continue
+		}
+
+		buckets := make([]bucket, len(keys))
+		var usage int64
+		for j, key := range keys {
+			if buckets[j], err = parseBucket(key, now.Location()); err != nil {
+				return time.Time{}, nil, err
+			}
+			buckets[j].usage = usages[i]
+			usage += usages[i]
+			i++
+		}
+		conditions = append(conditions, Condition{Keys: keys, Limit: usage})
+
+		if free := freedAt(buckets, time.Duration(duration.Seconds())*time.Second, limits[duration]-cost); free.After(at) {
+			at = free
+		}
+	}
+
+	return at, conditions, nil
+}
+
+// freedAt returns the earliest time the usage of the buckets still in a window of the given length is at most max.
+// A bucket leaves the window once its whole period is older than the window.
+func freedAt(buckets []bucket, window time.Duration, max int64) time.Time {
+	var usage int64
+	for _, b := range buckets {
+		usage += b.usage
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].start.Add(buckets[i].length).Before(buckets[j].start.Add(buckets[j].length))
+	})
+
+	at := time.Time{}
+	for _, b := range buckets {
+		if usage <= max {
+			break
+		}
+
+		usage -= b.usage
+		at = b.start.Add(b.length).Add(window)
+	}
+
+	return at
+}
+
+// parseBucket returns the period of the bucket stored at key from its timestamp suffix.
+func parseBucket(key string, loc *time.Location) (bucket, error) {
+	timestamp := key[strings.LastIndex(key, ":")+1:]
+	for _, b := range []struct {
+		format string
+		length time.Duration
+	}{
+		{secondFormat, time.Second},
+		{minuteFormat, time.Minute},
+		{hourFormat, time.Hour},
+	} {
+		if len(timestamp) != len(b.format) {
+			continue
+		}
+
+		start, err := time.ParseInLocation(b.format, timestamp, loc)
+		if err != nil {
+			return bucket{}, err
+		}
+
+		return bucket{start: start, length: b.length}, nil
+	}
+
+	return bucket{}, fmt.Errorf("limiter: unexpected bucket key %q", key)
+}
@@ -0,0 +1,88 @@
+package limiter_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/hendrywiranto/limiter"
+	"github.com/hendrywiranto/limiter/memory"
+)
+
+var waitLimits = map[string]limiter.Limits{"jobs": {limiter.DurationSecond: 5, limiter.DurationMinute: 10}}
+
+func (s *LimiterSuite) TestWaitAvailable() {
+	adapter := memory.NewAdapter()
+	s.newLimiter(adapter, waitLimits)
+
+	s.Require().NoError(s.l.WaitFor(s.ctx, "jobs", "42", 3))
+
+	sum, err := adapter.SumKeys(s.ctx, []string{"jobs:42:20240229231111"})
+	s.Require().NoError(err)
+	s.Equal(int64(3), sum)
+}
+
+func (s *LimiterSuite) TestWaitUntilWindowHasRoom() {
+	// let the clock run from 23:11:12.9 so the current second is over in 100ms.
+	start := time.Now()
+	limiter.Now = func() time.Time {
+		return time.Date(2024, 0o2, 29, 23, 11, 12, 900_000_000, time.UTC).Add(time.Since(start))
+	}
+	s.newLimiter(memory.NewAdapter(), waitLimits)
+	s.Require().NoError(s.l.RecordFor(s.ctx, "jobs", "42", 5))
+
+	// the second bucket is counted by the second window until 23:11:14.
+	s.Require().NoError(s.l.WaitFor(s.ctx, "jobs", "42", 1))
+	s.GreaterOrEqual(time.Since(start), time.Second)
+}
+
+func (s *LimiterSuite) TestWaitDeadlineTooEarly() {
+	adapter := memory.NewAdapter()
+	s.newLimiter(adapter, waitLimits)
+	s.Require().NoError(adapter.IncrBy(s.ctx, "jobs:42:20240229231030", 10))
+
+	// the minute bucket leaves the minute window at 23:11:31, in 20s.
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+	start := time.Now()
+
+	err := s.l.WaitFor(ctx, "jobs", "42", 1)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "available after the context deadline")
+	s.Less(time.Since(start), time.Second)
+}
+
+func (s *LimiterSuite) TestWaitCancelled() {
+	adapter := memory.NewAdapter()
+	s.newLimiter(adapter, waitLimits)
+	s.Require().NoError(adapter.IncrBy(s.ctx, "jobs:42:20240229231030", 10))
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	cancel()
+
+	err := s.l.WaitFor(ctx, "jobs", "42", 1)
+	s.ErrorIs(err, context.Canceled)
+}
+
+func (s *LimiterSuite) TestWaitNeverFits() {
+	s.newLimiter(memory.NewAdapter(), waitLimits)
+
+	err := s.l.Wait(s.ctx, "jobs", 6)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+
+	err = s.l.Wait(s.ctx, "unknown", 1)
+	s.ErrorIs(err, limiter.ErrMetricNotFound)
+}
+
+func (s *LimiterSuite) TestWaitWithoutSubject() {
+	s.newLimiter(memory.NewAdapter(), waitLimits)
+	s.Require().NoError(s.l.Record(s.ctx, "jobs", 10))
+	s.setNow(time.Date(2024, 0o2, 29, 23, 11, 20, 0, time.UTC))
+
+	// the usage recorded at 23:11:11 leaves the minute window at 23:12:12, in 52s.
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	err := s.l.Wait(ctx, "jobs", 1)
+	s.ErrorIs(err, limiter.ErrLimitExceeded)
+	s.ErrorContains(err, "available after the context deadline")
+}